@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/artyom/outline/outlinetest"
+)
+
+// newTestServer starts the fake Outline API and points the client at it for
+// the duration of the test
+func newTestServer(t *testing.T) (*outlinetest.Server, authToken) {
+	t.Helper()
+	srv := outlinetest.NewServer()
+	t.Cleanup(srv.Close)
+	orig := baseURL
+	baseURL = srv.URL
+	t.Cleanup(func() { baseURL = orig })
+	return srv, authToken(srv.Token)
+}
+
+func TestCreateDocument(t *testing.T) {
+	srv, token := newTestServer(t)
+	c := srv.AddCollection("Docs")
+	ctx := context.Background()
+	d, err := createDocument(ctx, token, newDocument{Title: "Hello", Text: "Hello, world.", Collection: c.ID, Publish: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Title != "Hello" || d.ID == "" || d.URL == "" {
+		t.Fatalf("unexpected document: %+v", d)
+	}
+	got, ok := srv.Document(d.ID)
+	if !ok {
+		t.Fatalf("document %s not found on the server", d.ID)
+	}
+	if got.Text != "Hello, world." || got.CollectionID != c.ID || got.PublishedAt == nil {
+		t.Fatalf("unexpected server document: %+v", got)
+	}
+	info, err := documentInfo(ctx, token, d.UrlID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != d.ID {
+		t.Fatalf("documentInfo by urlId returned %s, want %s", info.ID, d.ID)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	srv, token := newTestServer(t)
+	c := srv.AddCollection("Docs")
+	const total = 250 // spans several pages of 100
+	for range total {
+		srv.AddDocument(c.ID, "", "Doc", "")
+	}
+	ctx := context.Background()
+	for _, limit := range []int{0, 150} {
+		want := total
+		if limit > 0 {
+			want = limit
+		}
+		seen := make(map[string]bool)
+		for d, err := range listDocuments(ctx, token, c.ID, limit) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if seen[d.ID] {
+				t.Fatalf("limit %d: document %s returned twice", limit, d.ID)
+			}
+			seen[d.ID] = true
+		}
+		if len(seen) != want {
+			t.Fatalf("limit %d: got %d documents, want %d", limit, len(seen), want)
+		}
+	}
+}
+
+func TestAPIRequestErrors(t *testing.T) {
+	_, token := newTestServer(t)
+	ctx := context.Background()
+	_, err := apiRequest[*document](ctx, token, apiURL("documents.info"), struct {
+		ID string `json:"id"`
+	}{"no-such-document"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got error %v, want ErrNotFound", err)
+	}
+	var e *apiError
+	if !errors.As(err, &e) || e.Name == "" {
+		t.Fatalf("error %#v does not carry the API error name", err)
+	}
+	if _, err := documentInfo(ctx, "wrong-token", "whatever"); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("got error %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Errors API requests fail with, check them with errors.Is. The *apiError
+// returned holds the details.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrRateLimited     = errors.New("rate limited")
+	ErrPayloadTooLarge = errors.New("payload too large")
+)
+
+// apiError is a non-200 API response. Outline describes errors with the
+// {"ok":false,"error":"not_found","message":"..."} JSON body, responses of
+// proxies in front of it may lack it.
+type apiError struct {
+	Status     int           // HTTP status code
+	Name       string        // error name like "not_found", empty if response has no error body
+	Message    string        // human-readable message, may be empty
+	RetryAfter time.Duration // how soon to retry rate limited request, if known
+}
+
+func (e *apiError) Error() string {
+	s := fmt.Sprintf("unexpected status: %d %s", e.Status, http.StatusText(e.Status))
+	switch {
+	case e.Message != "":
+		s += ": " + e.Message
+	case e.Name != "":
+		s += ": " + e.Name
+	}
+	if e.RetryAfter > 0 {
+		s += fmt.Sprintf(" (retry after %v)", e.RetryAfter)
+	}
+	return s
+}
+
+func (e *apiError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.Status == http.StatusUnauthorized
+	case ErrRateLimited:
+		return e.Status == http.StatusTooManyRequests
+	case ErrPayloadTooLarge:
+		return e.Status == http.StatusRequestEntityTooLarge
+	}
+	return false
+}
+
+// responseError turns non-200 response into *apiError, decoding the error
+// body if there is one
+func responseError(resp *http.Response) error {
+	e := &apiError{Status: resp.StatusCode}
+	var body struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body) == nil {
+		e.Name, e.Message = body.Error, body.Message
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		e.RetryAfter, _ = retryAfter(resp)
+	}
+	return e
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"rsc.io/markdown"
+)
+
+func handleAttachments(ctx context.Context, token authToken, cliargs []string) error {
+	if len(cliargs) == 0 || cliargs[0] != "report" {
+		return fmt.Errorf("usage: %s attachments report [flags]", exeName)
+	}
+	var dstFile, collection string
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s attachments report -collection id [flags]\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+	fs.StringVar(&collection, "collection", collection, "collection id or name to report attachments of")
+	fs.Parse(cliargs[1:])
+	if collection == "" {
+		return errors.New("-collection flag must be set")
+	}
+	var err error
+	if collection, err = collectionID(ctx, token, collection); err != nil {
+		return err
+	}
+	type row struct {
+		attachment
+		docTitle string
+	}
+	var rows []row
+	var total int64
+	for d, err := range listDocuments(ctx, token, collection, 0) {
+		if err != nil {
+			return err
+		}
+		for a, err := range listAttachments(ctx, token, d.ID) {
+			if err != nil {
+				return err
+			}
+			rows = append(rows, row{attachment: a, docTitle: d.Title})
+			total += a.Size
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b row) int { return cmp.Compare(b.Size, a.Size) })
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "SIZE\tTYPE\tNAME\tDOCUMENT")
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", humanSize(r.Size), r.ContentType, r.Name, r.docTitle)
+	}
+	tw.Flush()
+	fmt.Fprintf(&buf, "\n%d attachments, %s total\n", len(rows), humanSize(total))
+	return writeResult(dstFile, buf.Bytes())
+}
+
+// listAttachments iterates over attachments of a single document
+func listAttachments(ctx context.Context, token authToken, docID string) iter.Seq2[attachment, error] {
+	return paginate[attachment](ctx, token, apiURL("attachments.list"), 0, func(p pagination) any {
+		return struct {
+			pagination
+			Document string `json:"documentId"`
+		}{pagination: p, Document: docID}
+	})
+}
+
+// uploadAttachment uploads contents of the local file name as an attachment to
+// the document with a given id. Attachment url is suitable for embedding into
+// document text.
+func uploadAttachment(ctx context.Context, token authToken, docID, name string, data []byte) (*attachment, error) {
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	req := struct {
+		Name        string `json:"name"`
+		Document    string `json:"documentId,omitempty"`
+		ContentType string `json:"contentType"`
+		Size        int    `json:"size"`
+		Preset      string `json:"preset"`
+	}{
+		Name:        filepath.Base(name),
+		Document:    docID,
+		ContentType: contentType,
+		Size:        len(data),
+		Preset:      "documentAttachment",
+	}
+	res, err := apiRequest[struct {
+		UploadURL  string            `json:"uploadUrl"`
+		Form       map[string]string `json:"form"`
+		Attachment attachment        `json:"attachment"`
+	}](ctx, token, apiURL("attachments.create"), req)
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range res.Form {
+		if err := mw.WriteField(k, v); err != nil {
+			return nil, err
+		}
+	}
+	// file must be the last field for S3-style presigned uploads
+	hdr := make(textproto.MIMEHeader)
+	hdr.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, req.Name))
+	hdr.Set("Content-Type", contentType)
+	w, err := mw.CreatePart(hdr)
+	if err != nil {
+		return nil, err
+	}
+	w.Write(data)
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	uploadURL, err := url.Parse(res.UploadURL)
+	if err != nil {
+		return nil, err
+	}
+	var sameHost bool
+	if !uploadURL.IsAbs() {
+		// Outline instances storing files locally return relative upload url
+		base, _ := url.Parse(baseURL + "/")
+		uploadURL = base.ResolveReference(uploadURL)
+		sameHost = true
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL.String(), &body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", mw.FormDataContentType())
+	if sameHost {
+		setCommonHeaders(httpReq.Header)
+		httpReq.Header.Set("Authorization", token.bearer())
+	}
+	resp, err := doRetry(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("uploading %s: unexpected status: %s", name, resp.Status)
+	}
+	return &res.Attachment, nil
+}
+
+// downloadImages saves images attached to the document into the directory
+// dir, and rewrites image links to point to the saved files, relative to
+// the baseDir.
+func downloadImages(ctx context.Context, token authToken, doc *markdown.Document, dir, baseDir string) error {
+	saved := make(map[string]string) // attachment url to local link
+	names := make(map[string]struct{})
+	for inl := range docInlines(doc) {
+		img, ok := inl.(*markdown.Image)
+		if !ok || !isAttachmentURL(img.URL) {
+			continue
+		}
+		if link, ok := saved[img.URL]; ok {
+			img.URL = link
+			continue
+		}
+		name, err := downloadAttachment(ctx, token, img.URL, dir, names)
+		if err != nil {
+			return err
+		}
+		link, err := filepath.Rel(baseDir, name)
+		if err != nil {
+			return err
+		}
+		link = filepath.ToSlash(link)
+		saved[img.URL] = link
+		img.URL = link
+	}
+	return nil
+}
+
+// isAttachmentURL reports whether link points to the file attached to
+// a document
+func isAttachmentURL(link string) bool {
+	link = strings.TrimPrefix(link, webURL(""))
+	return strings.HasPrefix(link, "/api/attachments.redirect?")
+}
+
+// downloadAttachment saves attachment into the directory dir, and returns
+// the name of the created file. File names are picked to not clash with the
+// names already in the names set, the new name is added to the set.
+func downloadAttachment(ctx context.Context, token authToken, link, dir string, names map[string]struct{}) (string, error) {
+	resp, err := openAttachment(ctx, token, link)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	base := attachmentFileName(resp)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	for i := 2; ; i++ {
+		if _, ok := names[strings.ToLower(base)]; !ok {
+			break
+		}
+		base = fmt.Sprintf("%s-%d%s", stem, i, ext)
+	}
+	names[strings.ToLower(base)] = struct{}{}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return "", err
+	}
+	name := filepath.Join(dir, base)
+	return name, saveBody(name, resp.Body)
+}
+
+// openAttachment requests attachment by its url, caller is expected to close
+// the response body
+func openAttachment(ctx context.Context, token authToken, link string) (*http.Response, error) {
+	if strings.HasPrefix(link, "/") {
+		link = webURL(link)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	setCommonHeaders(req.Header)
+	// token is only sent to the Outline instance itself, never to arbitrary
+	// urls; http.Client drops this header on redirects to other hosts, like
+	// presigned storage urls
+	if strings.HasPrefix(link, webURL("/")) {
+		req.Header.Set("Authorization", token.bearer())
+	}
+	resp, err := doRetry(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("downloading %s: unexpected status: %s", link, resp.Status)
+	}
+	return resp, nil
+}
+
+// attachmentFileName picks a safe local file name for the downloaded
+// attachment
+func attachmentFileName(resp *http.Response) string {
+	base := path.Base(resp.Request.URL.Path)
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		base = filepath.Base(params["filename"])
+	}
+	ext := filepath.Ext(base)
+	if ext == "" {
+		if exts, _ := mime.ExtensionsByType(resp.Header.Get("Content-Type")); len(exts) != 0 {
+			ext = exts[0]
+		}
+	}
+	return fileName(strings.TrimSuffix(base, filepath.Ext(base))) + ext
+}
+
+// saveBody writes everything read from r to the file name
+func saveBody(name string, r io.Reader) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func handleAttach(ctx context.Context, token authToken, cliargs []string) error {
+	usage := fmt.Errorf("usage: %s attach upload|get [flags] ...", exeName)
+	if len(cliargs) == 0 {
+		return usage
+	}
+	switch cliargs[0] {
+	case "upload":
+		var docID string
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s attach upload [-document url|urlid] file\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&docID, "document", docID, "document url|urlid to attach file to")
+		args := parseInterspersed(fs, cliargs[1:])
+		if len(args) == 0 {
+			return errors.New("want file to upload as the first positional argument")
+		}
+		if docID != "" {
+			if docID = documentID(docID); !uuidRe.MatchString(docID) {
+				d, err := documentInfo(ctx, token, docID)
+				if err != nil {
+					return err
+				}
+				docID = d.ID
+			}
+		}
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		a, err := uploadAttachment(ctx, token, docID, args[0], data)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\t%s\n", a.ID, webURL(a.URL))
+		return nil
+	case "get":
+		var dstFile string
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s attach get [-o file] attachment-id|url\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&dstFile, "o", dstFile, "file to save attachment to, if not set, it is saved to the current directory under its own name")
+		args := parseInterspersed(fs, cliargs[1:])
+		if len(args) == 0 {
+			return errors.New("want attachment id as the first positional argument")
+		}
+		link := args[0]
+		if !strings.Contains(link, "/") {
+			link = "/api/attachments.redirect?id=" + url.QueryEscape(link)
+		}
+		resp, err := openAttachment(ctx, token, link)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if dstFile == "-" {
+			_, err := io.Copy(os.Stdout, resp.Body)
+			return err
+		}
+		if dstFile == "" {
+			dstFile = attachmentFileName(resp)
+		}
+		return saveBody(dstFile, resp.Body)
+	}
+	return usage
+}
+
+type attachment struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url"`
+	DocumentID  string    `json:"documentId"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// humanSize formats byte count using binary units
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
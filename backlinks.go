@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"iter"
+
+	"rsc.io/markdown"
+)
+
+func handleBacklinks(ctx context.Context, token authToken, cliargs []string) error {
+	var dstFile string
+	var search bool
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s backlinks [flags] url|urlid\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+	fs.BoolVar(&search, "search", search, "also scan the workspace with full-text search for the document url, checking each result\n"+
+		"actually links to the document; finds links Outline does not track as backlinks, like the ones by absolute url")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url or id")
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	d, err := documentInfo(ctx, token, urlid)
+	if err != nil {
+		return err
+	}
+	if !search {
+		return writeDocuments(dstFile, backlinks(ctx, token, d.ID))
+	}
+	return writeDocuments(dstFile, func(yield func(document, error) bool) {
+		seen := make(map[string]bool)
+		for _, docs := range []iter.Seq2[document, error]{backlinks(ctx, token, d.ID), searchBacklinks(ctx, token, d)} {
+			for doc, err := range docs {
+				if err == nil && seen[doc.ID] {
+					continue
+				}
+				seen[doc.ID] = true
+				if !yield(doc, err) || err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// backlinks iterates over documents linking to the document with the given id
+func backlinks(ctx context.Context, token authToken, docID string) iter.Seq2[document, error] {
+	return paginate[document](ctx, token, apiURL("documents.list"), 0, func(p pagination) any {
+		return struct {
+			pagination
+			Backlink string `json:"backlinkDocumentId"`
+		}{pagination: p, Backlink: docID}
+	})
+}
+
+// searchBacklinks iterates over documents found by full-text search for the
+// urlId of the document d, which have links to d in their text
+func searchBacklinks(ctx context.Context, token authToken, d *document) iter.Seq2[document, error] {
+	return func(yield func(document, error) bool) {
+		q := searchQuery{Query: d.UrlID, Limit: 100}
+		for {
+			results, err := searchDocuments(ctx, token, q)
+			if err != nil {
+				yield(document{}, err)
+				return
+			}
+			for _, r := range results {
+				if r.Document.ID != d.ID && linksTo(r.Document.Text, d) && !yield(r.Document, nil) {
+					return
+				}
+			}
+			if len(results) < q.Limit {
+				return
+			}
+			q.Offset += len(results)
+		}
+	}
+}
+
+// linksTo reports whether markdown text has links to the document d
+func linksTo(text string, d *document) bool {
+	var p markdown.Parser
+	for link := range docLinks(p.Parse(text)) {
+		if id, _, ok := documentLink(link.URL); ok && (id == d.UrlID || id == d.ID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"rsc.io/markdown"
+)
+
+func handleCheck(ctx context.Context, token authToken, cliargs []string) error {
+	var opts transformOptions
+	var showDiff bool
+	td := textDiff{context: 3}
+	colorMode := "auto"
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s check [flags] directory\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nChecks that markdown files of the directory tree mapped to documents, either by the id in\n"+
+			"their front matter or by the %s file, match the documents, without changing anything.\n"+
+			"Files are transformed the same way update does it; images referenced by local paths are\n"+
+			"assumed to match the document ones at the same positions. Files out of sync are listed,\n"+
+			"and the exit status is non-zero if there are any, which makes it suitable for CI.\n", manifestName)
+	}
+	fs.BoolVar(&opts.numberHeadings, "number-headings", opts.numberHeadings, "prefix headings with hierarchical section numbers, see the same update flag")
+	fs.BoolVar(&opts.keepH1, "keep-h1", opts.keepH1, "keep the leading H1 heading in the document text")
+	fs.BoolVar(&showDiff, "diff", showDiff, "show the diff between each document and the file out of sync")
+	fs.IntVar(&td.context, "context", td.context, "number of context lines to show in the diff")
+	fs.StringVar(&colorMode, "color", colorMode, "colorize diff: auto, always, or never")
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != 1 {
+		return errors.New("want directory as the only positional argument")
+	}
+	dir := args[0]
+	var err error
+	if td.color, err = useColor(colorMode, os.Stdout); err != nil {
+		return err
+	}
+	m, err := readManifest(dir)
+	if err != nil {
+		return err
+	}
+	files, err := markdownFiles(dir)
+	if err != nil {
+		return err
+	}
+	// first pass maps files to documents, so links between files anywhere
+	// in the tree can be rewritten
+	type mapped struct {
+		name, localName, title string
+		doc                    *markdown.Document
+		id                     string
+	}
+	var todo []mapped
+	ids := make(map[string]string) // absolute file name to document id
+	for _, name := range files {
+		localName := filepath.Join(dir, filepath.FromSlash(name))
+		title, doc, meta, err := readDocument(localName, opts)
+		if err != nil {
+			return err
+		}
+		id := meta.ID
+		if e, ok := m.Documents[name]; ok && id == "" && e.Hash != "" {
+			id = e.ID
+		}
+		if id == "" {
+			continue
+		}
+		id = documentID(id)
+		abs, err := filepath.Abs(localName)
+		if err != nil {
+			return err
+		}
+		ids[abs] = id
+		todo = append(todo, mapped{name: name, localName: localName, title: title, doc: doc, id: id})
+	}
+	var drifted int
+	var p markdown.Parser
+	for _, f := range todo {
+		name, localName, doc := f.name, f.localName, f.doc
+		d, err := documentInfo(ctx, token, f.id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		ld, err := loadLocalDocs(filepath.Dir(localName))
+		if err != nil {
+			return err
+		}
+		for file, id := range ids {
+			if _, ok := ld.ids[file]; !ok {
+				ld.ids[file], ld.files[id] = id, file
+			}
+		}
+		if _, err := ld.toOutline(ctx, token, doc, filepath.Dir(localName)); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		remote := p.Parse(d.Text)
+		matchLocalImages(doc, remote)
+		oldText := string(documentMarkdown(d.Title, markdown.Format(remote)))
+		newText := string(documentMarkdown(cmp.Or(f.title, d.Title), markdown.Format(doc)))
+		if oldText == newText {
+			continue
+		}
+		drifted++
+		fmt.Printf("%s\t%s\n", name, webURL(d.URL))
+		if showDiff {
+			td.write(os.Stdout, "remote", localName, oldText, newText)
+		}
+	}
+	if drifted != 0 {
+		return fmt.Errorf("%d of %d mapped file(s) out of sync", drifted, len(todo))
+	}
+	return nil
+}
+
+// matchLocalImages replaces urls of doc images pointing to local files with
+// urls of remote images at the same positions, as update uploads local images
+// and links to the attachments instead. Nothing is replaced if documents have
+// different number of images.
+func matchLocalImages(doc, remote *markdown.Document) {
+	images := func(doc *markdown.Document) []*markdown.Image {
+		var out []*markdown.Image
+		for inl := range docInlines(doc) {
+			if img, ok := inl.(*markdown.Image); ok {
+				out = append(out, img)
+			}
+		}
+		return out
+	}
+	local, other := images(doc), images(remote)
+	if len(local) != len(other) {
+		return
+	}
+	for i, img := range local {
+		if isLocalPath(img.URL) {
+			img.URL = other[i].URL
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func handleExportCollection(ctx context.Context, token authToken, cliargs []string) error {
+	var nameTmpl string
+	var pageMeta bool
+	collection := defaultCollection
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s export-collection -collection id [flags] directory\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), nameTemplateHelp)
+	}
+	fs.StringVar(&collection, "collection", collection, "id or name of the collection to export")
+	fs.StringVar(&nameTmpl, "name-template", nameTmpl, "`template` of the file paths documents are saved to, relative to the directory;\n"+
+		"by default, documents are saved as Title.md, with their child documents in the Title directory")
+	fs.BoolVar(&pageMeta, "front-matter", pageMeta, "save documents for Hugo or Jekyll: with YAML front matter holding title, date, slug,\n"+
+		"and weight following the collection order, without the title heading, and with links to headings\n"+
+		"in github style")
+	args := parseInterspersed(fs, cliargs)
+	if len(args) == 0 {
+		return errors.New("want destination directory as the first positional argument")
+	}
+	if collection == "" {
+		return errors.New("-collection flag must be set")
+	}
+	var err error
+	if collection, err = collectionID(ctx, token, collection); err != nil {
+		return err
+	}
+	var nt *nameTemplate
+	var collectionName string
+	if nameTmpl != "" {
+		if nt, err = parseNameTemplate(nameTmpl); err != nil {
+			return err
+		}
+		c, err := collectionInfo(ctx, token, collection)
+		if err != nil {
+			return err
+		}
+		collectionName = c.Name
+	}
+	nodes, err := collectionTree(ctx, token, collection)
+	if err != nil {
+		return err
+	}
+	// dir is relative to the destination directory, parents are titles of
+	// the parent documents
+	var walk func(dir string, parents []string, nodes []navNode) error
+	walk = func(dir string, parents []string, nodes []navNode) error {
+		names := make(map[string]struct{})
+		for i, n := range nodes {
+			name := uniqueName(names, fileName(n.Title))
+			d, err := documentInfo(ctx, token, n.ID)
+			if err != nil {
+				return err
+			}
+			dst := filepath.Join(dir, name+".md")
+			if nt != nil {
+				s, err := nt.name(nameTemplateData{
+					ID:         d.ID,
+					UrlID:      d.UrlID,
+					Title:      d.Title,
+					Collection: collectionName,
+					Parents:    parents,
+					Path:       filepath.ToSlash(filepath.Join(dir, name)),
+					UpdatedAt:  d.UpdatedAt,
+				})
+				if err != nil {
+					return err
+				}
+				dst = filepath.FromSlash(s)
+			}
+			dst = filepath.Join(args[0], dst)
+			if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+				return err
+			}
+			data := documentMarkdown(d.Title, d.Text)
+			if pageMeta {
+				if data, err = pageMarkdown(d, i+1); err != nil {
+					return err
+				}
+			}
+			if err := os.WriteFile(dst, data, 0666); err != nil {
+				return err
+			}
+			if len(n.Children) != 0 {
+				if err := walk(filepath.Join(dir, name), append(parents[:len(parents):len(parents)], d.Title), n.Children); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := os.MkdirAll(args[0], 0777); err != nil {
+		return err
+	}
+	return walk("", nil, nodes)
+}
+
+// pageMarkdown returns document text with the front matter for static site
+// generators; weight is the document position among its siblings
+func pageMarkdown(d *document, weight int) ([]byte, error) {
+	date := d.CreatedAt
+	if d.PublishedAt != nil {
+		date = *d.PublishedAt
+	}
+	meta, err := encodeFrontMatter(pageFrontMatter{
+		Title:   d.Title,
+		Date:    date,
+		Lastmod: d.UpdatedAt,
+		Slug:    fileSlug(d.Title),
+		Weight:  weight,
+	})
+	if err != nil {
+		return nil, err
+	}
+	text := localText(d.Text)
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return append(meta, text...), nil
+}
+
+// navNode is a node of the collection document structure, as returned by the
+// collections.documents endpoint
+type navNode struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	URL      string    `json:"url"`
+	Children []navNode `json:"children"`
+}
+
+// collectionTree returns collection document structure
+func collectionTree(ctx context.Context, token authToken, collection string) ([]navNode, error) {
+	req := struct {
+		Id string `json:"id"`
+	}{Id: collection}
+	return apiRequest[[]navNode](ctx, token, apiURL("collections.documents"), req)
+}
+
+// fileName turns document title into a string safe to use as a file name
+func fileName(title string) string {
+	s := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		if r < ' ' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(title))
+	s = strings.TrimLeft(s, ".")
+	if s == "" {
+		s = "untitled"
+	}
+	return s
+}
+
+// uniqueName returns name, adding a numeric suffix to it if it is already
+// present in the seen set; the returned name is added to the set.
+func uniqueName(seen map[string]struct{}, name string) string {
+	out := name
+	for i := 2; ; i++ {
+		if _, ok := seen[strings.ToLower(out)]; !ok {
+			break
+		}
+		out = name + " " + strconv.Itoa(i)
+	}
+	seen[strings.ToLower(out)] = struct{}{}
+	return out
+}
+
+type collection struct {
+	ID          string `json:"id"`
+	UrlID       string `json:"urlId"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	Icon        string `json:"icon"`
+	Permission  string `json:"permission"`
+}
+
+// collectionInfo fetches a single collection by its id
+func collectionInfo(ctx context.Context, token authToken, id string) (*collection, error) {
+	req := struct {
+		Id string `json:"id"`
+	}{Id: id}
+	return apiRequest[*collection](ctx, token, apiURL("collections.info"), req)
+}
+
+func listCollections(ctx context.Context, token authToken) iter.Seq2[collection, error] {
+	return paginate[collection](ctx, token, apiURL("collections.list"), 0, func(p pagination) any { return p })
+}
+
+func handleCollections(ctx context.Context, token authToken, cliargs []string) error {
+	usage := fmt.Errorf("usage: %s collections list|create|update|delete [flags] ...", exeName)
+	if len(cliargs) == 0 {
+		return usage
+	}
+	// attrFlags registers flags for collection attributes, only the flags set
+	// are sent to the API
+	var attrs collectionAttrs
+	attrFlags := func(fs *flag.FlagSet) {
+		for _, f := range []struct {
+			name, usage string
+			dst         **string
+		}{
+			{"name", "collection name", &attrs.Name},
+			{"description", "collection description in markdown", &attrs.Description},
+			{"color", "collection color as a hex `code`, like #4E5C6E", &attrs.Color},
+			{"icon", "collection icon `name` or emoji", &attrs.Icon},
+			{"permission", "default access for workspace members: read or read_write", &attrs.Permission},
+		} {
+			fs.Func(f.name, f.usage, func(s string) error {
+				*f.dst = &s
+				return nil
+			})
+		}
+	}
+	printCollection := func(c *collection) error {
+		if jsonOutput {
+			return writeJSON("", c)
+		}
+		fmt.Printf("%s\t%s\n", c.ID, c.Name)
+		return nil
+	}
+	switch cliargs[0] {
+	case "list":
+		var dstFile string
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s collections list [flags]\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+		fs.Parse(cliargs[1:])
+		var buf bytes.Buffer
+		out := []collection{}
+		for c, err := range listCollections(ctx, token) {
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				out = append(out, c)
+				continue
+			}
+			fmt.Fprintf(&buf, "%s\t%s\n", c.ID, c.Name)
+		}
+		if jsonOutput {
+			return writeJSON(dstFile, out)
+		}
+		return writeResult(dstFile, buf.Bytes())
+	case "create":
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s collections create -name name [flags]\n", exeName)
+			fs.PrintDefaults()
+		}
+		attrFlags(fs)
+		fs.Parse(cliargs[1:])
+		if attrs.Name == nil || *attrs.Name == "" {
+			return errors.New("-name flag must be set")
+		}
+		c, err := saveCollection(ctx, token, apiURL("collections.create"), attrs)
+		if err != nil {
+			return err
+		}
+		return printCollection(c)
+	case "update":
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s collections update [flags] collection-id\n", exeName)
+			fs.PrintDefaults()
+			fmt.Fprintln(fs.Output(), "\nOnly attributes set with flags are changed.")
+		}
+		attrFlags(fs)
+		args := parseInterspersed(fs, cliargs[1:])
+		if len(args) != 1 {
+			return errors.New("want exactly one collection id")
+		}
+		attrs.ID = args[0]
+		c, err := saveCollection(ctx, token, apiURL("collections.update"), attrs)
+		if err != nil {
+			return err
+		}
+		return printCollection(c)
+	case "delete":
+		var yes bool
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s collections delete [-yes] collection-id\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.BoolVar(&yes, "yes", yes, "do not ask for confirmation")
+		args := parseInterspersed(fs, cliargs[1:])
+		if len(args) != 1 {
+			return errors.New("want exactly one collection id")
+		}
+		if !yes {
+			c, err := collectionInfo(ctx, token, args[0])
+			if err != nil {
+				return err
+			}
+			if ok, err := confirm(fmt.Sprintf("Delete collection %q with all its documents?", c.Name)); err != nil || !ok {
+				return err
+			}
+		}
+		req := struct {
+			Id string `json:"id"`
+		}{Id: args[0]}
+		return apiCall(ctx, token, apiURL("collections.delete"), req)
+	}
+	return usage
+}
+
+// collectionAttrs holds collection attributes to create or update a collection
+// with, unset attributes are not sent
+type collectionAttrs struct {
+	ID          string  `json:"id,omitempty"`
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Color       *string `json:"color,omitempty"`
+	Icon        *string `json:"icon,omitempty"`
+	Permission  *string `json:"permission,omitempty"`
+}
+
+func saveCollection(ctx context.Context, token authToken, endpoint string, attrs collectionAttrs) (*collection, error) {
+	return apiRequest[*collection](ctx, token, endpoint, attrs)
+}
+
+// collectionID returns id of the collection given by its id, url, or name
+// matched case-insensitively. Empty string is returned as is.
+func collectionID(ctx context.Context, token authToken, s string) (string, error) {
+	if s == "" || uuidRe.MatchString(s) {
+		return s, nil
+	}
+	var found []collection
+	for c, err := range listCollections(ctx, token) {
+		if err != nil {
+			return "", err
+		}
+		if strings.EqualFold(c.Name, s) || c.UrlID == documentID(s) {
+			found = append(found, c)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no collection named %q", s)
+	case 1:
+		return found[0].ID, nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "collection name %q is ambiguous, matching collections:", s)
+	for _, c := range found {
+		fmt.Fprintf(&b, "\n\t%s\t%s", c.ID, c.Name)
+	}
+	return "", errors.New(b.String())
+}
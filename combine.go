@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+// combinedPart is a document of the subtree being combined into one file
+type combinedPart struct {
+	d      *document
+	doc    *markdown.Document
+	depth  int      // 0 for the root document
+	title  int      // index of the title heading among top-level headings of the combined document
+	slugs  []string // outline-style slugs of the document headings
+	offset int      // index of the first document heading among top-level headings of the combined document
+}
+
+// combineDocuments combines the document with the given id and all its nested
+// child documents, in the collection structure order, into a single markdown
+// document. Each document becomes a section titled after it, with headings
+// shifted according to the document nesting depth. Links between documents of
+// the subtree and to their headings are rewritten to local anchors.
+func combineDocuments(ctx context.Context, token authToken, urlid string, opts getOptions) (*markdown.Document, error) {
+	root, err := documentInfo(ctx, token, urlid)
+	if err != nil {
+		return nil, err
+	}
+	var children []navNode
+	if root.CollectionID != "" {
+		nodes, err := collectionTree(ctx, token, root.CollectionID)
+		if err != nil {
+			return nil, err
+		}
+		// drafts are not part of the collection structure, and are
+		// combined without children
+		if n := findNode(nodes, root.ID); n != nil {
+			children = n.Children
+		}
+	}
+	var p markdown.Parser
+	var parts []*combinedPart
+	add := func(d *document, depth int) {
+		doc := p.Parse(d.Text)
+		if opts.numbered {
+			stripHeadingNumbers(doc)
+		}
+		parts = append(parts, &combinedPart{d: d, doc: doc, depth: depth, slugs: headingSlugs(doc, slugOutline)})
+	}
+	add(root, 0)
+	var walk func(nodes []navNode, depth int) error
+	walk = func(nodes []navNode, depth int) error {
+		for _, n := range nodes {
+			d, err := documentInfo(ctx, token, n.ID)
+			if err != nil {
+				return err
+			}
+			add(d, depth)
+			if err := walk(n.Children, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(children, 1); err != nil {
+		return nil, err
+	}
+
+	out := new(markdown.Document)
+	byID := make(map[string]*combinedPart, len(parts))
+	var headings int
+	for _, part := range parts {
+		byID[part.d.ID], byID[part.d.UrlID] = part, part
+		out.Blocks = append(out.Blocks, &markdown.Heading{
+			Level: min(part.depth+1, 6),
+			Text:  &markdown.Text{Inline: markdown.Inlines{&markdown.Plain{Text: part.d.Title}}},
+		})
+		part.title, part.offset = headings, headings+1
+		headings += 1 + len(part.slugs)
+		for _, b := range part.doc.Blocks {
+			if h, ok := b.(*markdown.Heading); ok {
+				h.Level = min(h.Level+part.depth+1, 6)
+			}
+			out.Blocks = append(out.Blocks, b)
+		}
+	}
+	anchors := headingSlugs(out, slugRegular)
+	// anchor returns local link to the part heading by its outline-style
+	// slug, or to the part title if slug is empty
+	anchor := func(part *combinedPart, slug string) (string, bool) {
+		if slug == "" {
+			return "#" + anchors[part.title], true
+		}
+		for i, s := range part.slugs {
+			if s == slug {
+				return "#" + anchors[part.offset+i], true
+			}
+		}
+		return "", false
+	}
+	for _, part := range parts {
+		for link := range docLinks(part.doc) {
+			if slug, ok := strings.CutPrefix(link.URL, "#"); ok {
+				if s, ok := anchor(part, slug); ok {
+					link.URL = s
+				}
+				continue
+			}
+			path, ok := strings.CutPrefix(strings.TrimPrefix(link.URL, webURL("")), "/doc/")
+			if !ok {
+				continue
+			}
+			path, slug, _ := strings.Cut(path, "#")
+			target, ok := byID[documentID(path)]
+			if !ok {
+				continue
+			}
+			if s, ok := anchor(target, slug); ok {
+				link.URL = s
+			} else {
+				link.URL, _ = anchor(target, "")
+			}
+		}
+	}
+	return out, nil
+}
+
+// findNode returns the node with the given document id from the collection
+// document structure, or nil if there is none
+func findNode(nodes []navNode, id string) *navNode {
+	for i := range nodes {
+		if nodes[i].ID == id {
+			return &nodes[i]
+		}
+		if n := findNode(nodes[i].Children, id); n != nil {
+			return n
+		}
+	}
+	return nil
+}
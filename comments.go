@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+func handleComments(ctx context.Context, token authToken, cliargs []string) error {
+	var dstFile string
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s comments [flags] url|urlid\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url or id")
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	d, err := documentInfo(ctx, token, urlid)
+	if err != nil {
+		return err
+	}
+	threads, err := commentThreads(ctx, token, d.ID)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return writeJSON(dstFile, threads)
+	}
+	var buf bytes.Buffer
+	writeComments(&buf, threads)
+	return writeResult(dstFile, buf.Bytes())
+}
+
+// comment holds a subset of comment attributes
+type comment struct {
+	ID        string     `json:"id"`
+	ParentID  string     `json:"parentCommentId,omitempty"`
+	Data      pmNode     `json:"data,omitzero"` // comment body, cleared once converted to Text
+	Text      string     `json:"text"`
+	CreatedAt time.Time  `json:"createdAt"`
+	CreatedBy user       `json:"createdBy"`
+	Replies   []*comment `json:"replies,omitempty"`
+}
+
+// convertText fills comment Text from its ProseMirror body, unless the API
+// already returned it
+func (c *comment) convertText() {
+	c.Text = cmp.Or(c.Text, c.Data.plainText())
+	c.Data = pmNode{}
+}
+
+// pmNode is a ProseMirror document node, the format comment bodies are stored in
+type pmNode struct {
+	Type    string   `json:"type,omitempty"`
+	Text    string   `json:"text,omitempty"`
+	Content []pmNode `json:"content,omitempty"`
+}
+
+// plainText returns node text, with block nodes separated by newlines
+func (n *pmNode) plainText() string {
+	var sb strings.Builder
+	var walk func(*pmNode)
+	walk = func(n *pmNode) {
+		switch n.Type {
+		case "text":
+			sb.WriteString(n.Text)
+		case "hard_break", "hardBreak":
+			sb.WriteByte('\n')
+		}
+		for i := range n.Content {
+			walk(&n.Content[i])
+		}
+		switch n.Type {
+		case "paragraph", "heading", "code_block", "list_item", "checkbox_item":
+			sb.WriteByte('\n')
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(sb.String())
+}
+
+// commentThreads fetches all comments of the document, and returns top-level
+// comments with the replies attached to them, in chronological order
+func commentThreads(ctx context.Context, token authToken, docID string) ([]*comment, error) {
+	var all []*comment
+	comments := paginate[*comment](ctx, token, apiURL("comments.list"), 0, func(p pagination) any {
+		return struct {
+			pagination
+			Document string `json:"documentId"`
+		}{pagination: p, Document: docID}
+	})
+	for c, err := range comments {
+		if err != nil {
+			return nil, err
+		}
+		c.convertText()
+		all = append(all, c)
+	}
+	slices.SortStableFunc(all, func(a, b *comment) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	byID := make(map[string]*comment, len(all))
+	for _, c := range all {
+		byID[c.ID] = c
+	}
+	var threads []*comment
+	for _, c := range all {
+		if p, ok := byID[c.ParentID]; ok {
+			p.Replies = append(p.Replies, c)
+			continue
+		}
+		threads = append(threads, c)
+	}
+	return threads, nil
+}
+
+// writeComments writes comment threads as a markdown list, replies nested
+// under the comments they answer
+func writeComments(w io.Writer, threads []*comment) {
+	var write func(cs []*comment, indent string)
+	write = func(cs []*comment, indent string) {
+		for _, c := range cs {
+			fmt.Fprintf(w, "%s- **%s**, %s:", indent, cmp.Or(c.CreatedBy.Name, "unknown user"), c.CreatedAt.Format(time.RFC3339))
+			for line := range strings.Lines(c.Text) {
+				fmt.Fprintf(w, "\n%s  %s", indent, strings.TrimRight(line, "\n"))
+			}
+			fmt.Fprintln(w)
+			write(c.Replies, indent+"  ")
+		}
+	}
+	write(threads, "")
+}
+
+func handleComment(ctx context.Context, token authToken, cliargs []string) error {
+	var text, replyTo string
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s comment -m text [flags] url|urlid\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&text, "m", text, "comment `text` in markdown, - to read it from stdin")
+	fs.StringVar(&replyTo, "reply-to", replyTo, "id of the comment to reply to, see comments subcommand")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url or id")
+	}
+	if text == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		text = string(data)
+	}
+	if text = strings.TrimSpace(text); text == "" {
+		return errors.New("-m flag must be set to a non-empty text")
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	d, err := documentInfo(ctx, token, urlid)
+	if err != nil {
+		return err
+	}
+	c, err := createComment(ctx, token, d.ID, replyTo, text)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return writeJSON("", c)
+	}
+	fmt.Println(c.ID)
+	return nil
+}
+
+// createComment adds a comment to the document, or a reply to the parent
+// comment if its id is not empty
+func createComment(ctx context.Context, token authToken, docID, parentID, text string) (*comment, error) {
+	req := struct {
+		Document string `json:"documentId"`
+		Parent   string `json:"parentCommentId,omitempty"`
+		Text     string `json:"text"`
+	}{Document: docID, Parent: parentID, Text: text}
+	res, err := apiRequest[comment](ctx, token, apiURL("comments.create"), req)
+	if err != nil {
+		return nil, err
+	}
+	res.convertText()
+	return &res, nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// baseURL is the root url of the Outline instance, without the trailing
+	// slash
+	baseURL = "https://app.getoutline.com"
+	// defaultCollection is the collection id or name subcommands use if their
+	// -collection flag is not set, taken from the selected profile
+	defaultCollection string
+)
+
+// apiURL returns url of the API method
+func apiURL(method string) string { return baseURL + "/api/" + method }
+
+const configHelp = `
+Configuration file %s holds named profiles for different
+Outline workspaces, profile is selected with the global -profile flag, OUTLINE_PROFILE environment
+variable, or the default key of the file:
+
+  default: work
+  profiles:
+    work:
+      baseURL: https://docs.example.com
+      tokenCommand: pass show outline/work  # command printing the token, run by shell
+      collection: 3a2f0f7e-98c5-4b38-86ad-0c8b5d3f6a41  # default for -collection flags
+    personal:
+      tokenEnv: OUTLINE_PERSONAL_TOKEN  # environment variable holding the token
+      # tokenFile: /run/secrets/outline  # file holding the token
+      # token: ...  # the token itself, only if the file is not readable by others
+
+Without a profile, OUTLINE_TOKEN and OUTLINE_BASE_URL environment variables are used. Instead of
+OUTLINE_TOKEN, OUTLINE_TOKEN_CMD may hold a command printing the token, like
+"op read op://vault/outline/token", or OUTLINE_TOKEN_FILE may name a file holding the token, like
+a mounted secret. These variables are ignored once a profile is selected. If no token is
+configured, the one saved in the system keychain by the login subcommand is used.
+`
+
+type config struct {
+	Default  string              `yaml:"default"`
+	Profiles map[string]*profile `yaml:"profiles"`
+}
+
+type profile struct {
+	BaseURL      string `yaml:"baseURL"`
+	Token        string `yaml:"token"`
+	TokenEnv     string `yaml:"tokenEnv"`
+	TokenCommand string `yaml:"tokenCommand"`
+	TokenFile    string `yaml:"tokenFile"`
+	Collection   string `yaml:"collection"`
+
+	name string // name of the profile, empty if no profile is selected
+}
+
+func configFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "outline", "config.yaml")
+}
+
+// loadProfile reads the named profile from the configuration file. If name is
+// empty, the default profile is used, if any. Missing configuration file is
+// only an error if the profile is requested by name. The returned profile is
+// never nil.
+func loadProfile(name string) (*profile, error) {
+	file := configFile()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && name == "" {
+			return &profile{}, nil
+		}
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var cfg config
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", file, err)
+	}
+	if name = cmp.Or(name, cfg.Default); name == "" {
+		return &profile{}, nil
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok || p == nil {
+		return nil, fmt.Errorf("%s: no profile %q", file, name)
+	}
+	p.BaseURL = strings.TrimSuffix(p.BaseURL, "/")
+	p.name = name
+	return p, nil
+}
+
+// authToken returns the token from the source configured by the profile.
+// Without a profile, the OUTLINE_TOKEN, OUTLINE_TOKEN_CMD, and
+// OUTLINE_TOKEN_FILE environment variables are used. Otherwise, and if they
+// are not set either, it falls back to the token saved in the system keychain
+// by the login subcommand. Profiles never use the environment variables, as
+// these likely hold a token of another workspace.
+func (p *profile) authToken(ctx context.Context) (authToken, error) {
+	switch {
+	case p.Token != "":
+		return authToken(p.Token), nil
+	case p.TokenEnv != "":
+		if s := os.Getenv(p.TokenEnv); s != "" {
+			return authToken(s), nil
+		}
+		return "", fmt.Errorf("%s is not set", p.TokenEnv)
+	case p.TokenCommand != "":
+		return tokenFromCommand(ctx, p.TokenCommand)
+	case p.TokenFile != "":
+		return tokenFromFile(p.TokenFile)
+	}
+	if p.name == "" {
+		if s := os.Getenv("OUTLINE_TOKEN"); s != "" {
+			return authToken(s), nil
+		}
+		if s := os.Getenv("OUTLINE_TOKEN_CMD"); s != "" {
+			return tokenFromCommand(ctx, s)
+		}
+		if s := os.Getenv("OUTLINE_TOKEN_FILE"); s != "" {
+			return tokenFromFile(s)
+		}
+	}
+	s, err := keychainGet(baseURL)
+	if err != nil {
+		if errors.Is(err, errNoKeychainToken) {
+			if p.name != "" {
+				return "", fmt.Errorf("profile %q has no token configured, and no token is saved for %s, see %s login -h", p.name, baseURL, exeName)
+			}
+			return "", fmt.Errorf("OUTLINE_TOKEN is not set, and no token is saved for %s, see %s login -h", baseURL, exeName)
+		}
+		return "", fmt.Errorf("reading token from the system keychain: %w", err)
+	}
+	return authToken(s), nil
+}
+
+// tokenFromCommand runs command with shell and returns its output as the token
+func tokenFromCommand(ctx context.Context, command string) (authToken, error) {
+	cmd := shellCommand(ctx, command)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("running token command: %w", err)
+	}
+	if s := strings.TrimSpace(string(out)); s != "" {
+		return authToken(s), nil
+	}
+	return "", errors.New("token command printed nothing")
+}
+
+// tokenFromFile reads the token from the file name, ignoring surrounding
+// whitespace
+func tokenFromFile(name string) (authToken, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("reading token: %w", err)
+	}
+	if s := strings.TrimSpace(string(data)); s != "" {
+		return authToken(s), nil
+	}
+	return "", fmt.Errorf("token file %s is empty", name)
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+func handleImportConfluence(ctx context.Context, token authToken, cliargs []string) error {
+	collection := defaultCollection
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s import-confluence -collection id [flags] space-export.zip\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nCreates documents from the Confluence space HTML export archive. Pages are converted to\n"+
+			"markdown and nested the same way they are in the space page tree listed in the export index.html.\n"+
+			"Links between pages are rewritten to the new documents, and page attachments are uploaded.")
+	}
+	fs.StringVar(&collection, "collection", collection, "id or name of the collection to create documents in")
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != 1 {
+		return errors.New("want Confluence export archive as the only positional argument")
+	}
+	if collection == "" {
+		return errors.New("-collection flag must be set")
+	}
+	var err error
+	if collection, err = collectionID(ctx, token, collection); err != nil {
+		return err
+	}
+	files, err := zipFiles(args[0])
+	if err != nil {
+		return err
+	}
+	var indexName string
+	for name := range files {
+		if path.Base(name) == "index.html" && (indexName == "" || len(name) < len(indexName)) {
+			indexName = name
+		}
+	}
+	if indexName == "" {
+		return errors.New("no index.html found in the archive, make sure it is a Confluence space HTML export")
+	}
+	data, err := files[indexName]()
+	if err != nil {
+		return err
+	}
+	tree := confluencePageTree(parseHTML(data), path.Dir(indexName), files)
+	if len(tree) == 0 {
+		return fmt.Errorf("no pages listed in %s", indexName)
+	}
+
+	// documents are created empty first, so the final text can link to any
+	// of them
+	var order []string
+	created := make(map[string]*importedPage)
+	attachNames := make(map[string]string) // archive file name to the original attachment name
+	var walk func(nodes []*confluencePage, parent string) error
+	walk = func(nodes []*confluencePage, parent string) error {
+		for _, n := range nodes {
+			data, err := files[n.file]()
+			if err != nil {
+				return fmt.Errorf("%s: %w", n.file, err)
+			}
+			text, names := confluenceMarkdown(parseHTML(data))
+			for k, v := range names {
+				attachNames[path.Join(path.Dir(n.file), k)] = v
+			}
+			var p markdown.Parser
+			d, err := createDocument(ctx, token, newDocument{
+				Title:      n.title,
+				Collection: collection,
+				Parent:     parent,
+				Publish:    true,
+			})
+			if err != nil {
+				return fmt.Errorf("creating document from %s: %w", n.file, err)
+			}
+			fmt.Printf("%s\t%s\t%s\n", n.file, d.ID, d.Title)
+			created[n.file] = &importedPage{d: d, doc: p.Parse(text)}
+			order = append(order, n.file)
+			if err := walk(n.children, d.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(tree, ""); err != nil {
+		return err
+	}
+	attachName := func(file string) string {
+		if s := attachNames[file]; s != "" {
+			return s
+		}
+		return path.Base(file)
+	}
+	var uploaded int
+	for _, name := range order {
+		n, err := linkArchiveFiles(ctx, token, files, created, name, attachName)
+		uploaded += n
+		if err != nil {
+			return err
+		}
+		if _, err := updateDocument(ctx, token, documentUpdate{Id: created[name].d.ID, Text: markdown.Format(created[name].doc)}); err != nil {
+			return fmt.Errorf("updating document created from %s: %w", name, err)
+		}
+	}
+	fmt.Printf("\n%d documents created, %d files attached\n", len(created), uploaded)
+	return nil
+}
+
+// confluencePage is a page of the Confluence space export page tree
+type confluencePage struct {
+	title    string
+	file     string // archive file name
+	children []*confluencePage
+}
+
+// confluencePageTree returns the space page tree from the export index page,
+// which lists pages as nested lists of links. Page files are resolved against
+// dir.
+func confluencePageTree(index *htmlNode, dir string, files map[string]func() ([]byte, error)) []*confluencePage {
+	var out []*confluencePage
+	seen := make(map[string]bool)
+	var walk func(n *htmlNode, parent *confluencePage)
+	walk = func(n *htmlNode, parent *confluencePage) {
+		for _, c := range n.children {
+			if c.tag != "li" {
+				walk(c, parent)
+				continue
+			}
+			page := parent
+			if a := c.find(func(x *htmlNode) bool { return x.tag == "a" }, "ul", "ol"); a != nil {
+				file := path.Join(dir, a.attr["href"])
+				if _, ok := files[file]; ok && path.Ext(file) == ".html" && !seen[file] {
+					seen[file] = true
+					page = &confluencePage{title: strings.TrimSpace(collapseSpace(a.text())), file: file}
+					if parent == nil {
+						out = append(out, page)
+					} else {
+						parent.children = append(parent.children, page)
+					}
+				}
+			}
+			walk(c, page)
+		}
+	}
+	walk(index, nil)
+	return out
+}
+
+// confluenceMarkdown converts the page of the Confluence HTML export to
+// markdown. It also returns original names of the page attachments by their
+// links, as attachment files are named after their ids in the export.
+func confluenceMarkdown(page *htmlNode) (string, map[string]string) {
+	content := page.find(func(n *htmlNode) bool { return n.attr["id"] == "main-content" })
+	if content == nil {
+		if content = page.find(func(n *htmlNode) bool { return n.tag == "body" }); content == nil {
+			content = page
+		}
+	}
+	text := htmlMarkdown(content.children)
+	names := make(map[string]string)
+	// attachments section follows the page content
+	section := page.find(func(n *htmlNode) bool {
+		return n.hasClass("pageSection") && n.find(func(x *htmlNode) bool { return x.attr["id"] == "attachments" }) != nil
+	})
+	if section == nil {
+		return text, names
+	}
+	var links []string
+	for a := range section.all(func(n *htmlNode) bool { return n.tag == "a" && n.attr["href"] != "" }) {
+		name := strings.TrimSpace(collapseSpace(a.text()))
+		if name == "" {
+			name = path.Base(a.attr["href"])
+		}
+		names[a.attr["href"]] = name
+		links = append(links, "- ["+escapeLinkText(name)+"]("+markdownURL(a.attr["href"])+")")
+	}
+	if len(links) != 0 {
+		text += "\n\n## Attachments\n\n" + strings.Join(links, "\n")
+	}
+	return text, names
+}
+
+// htmlNode is an element or a text node of the parsed HTML document
+type htmlNode struct {
+	tag      string // lowercase element name, empty for text nodes
+	attr     map[string]string
+	data     string // text of text nodes
+	children []*htmlNode
+}
+
+// parseHTML parses HTML document leniently, as the XML parser in non-strict
+// mode is good enough for the machine-generated HTML of exports
+func parseHTML(data []byte) *htmlNode {
+	root := &htmlNode{attr: map[string]string{}}
+	stack := []*htmlNode{root}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break // io.EOF, or the rest of the document is malformed
+		}
+		cur := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &htmlNode{tag: strings.ToLower(t.Name.Local), attr: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				n.attr[strings.ToLower(a.Name.Local)] = a.Value
+			}
+			cur.children = append(cur.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			tag := strings.ToLower(t.Name.Local)
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].tag == tag {
+					stack = stack[:i]
+					break
+				}
+			}
+		case xml.CharData:
+			cur.children = append(cur.children, &htmlNode{data: string(t)})
+		}
+	}
+	return root
+}
+
+// find returns the first node of the subtree, in document order, matching
+// the function. Subtrees of elements with the skip tags are not searched.
+func (n *htmlNode) find(match func(*htmlNode) bool, skip ...string) *htmlNode {
+	for _, c := range n.children {
+		if slices.Contains(skip, c.tag) {
+			continue
+		}
+		if match(c) {
+			return c
+		}
+		if x := c.find(match, skip...); x != nil {
+			return x
+		}
+	}
+	return nil
+}
+
+// all iterates over nodes of the subtree matching the function
+func (n *htmlNode) all(match func(*htmlNode) bool) func(yield func(*htmlNode) bool) {
+	return func(yield func(*htmlNode) bool) {
+		var walk func(n *htmlNode) bool
+		walk = func(n *htmlNode) bool {
+			for _, c := range n.children {
+				if match(c) && !yield(c) {
+					return false
+				}
+				if !walk(c) {
+					return false
+				}
+			}
+			return true
+		}
+		walk(n)
+	}
+}
+
+func (n *htmlNode) hasClass(class string) bool {
+	return slices.Contains(strings.Fields(n.attr["class"]), class)
+}
+
+// text returns the text content of the subtree
+func (n *htmlNode) text() string {
+	if n.tag == "" {
+		return n.data
+	}
+	var b strings.Builder
+	for _, c := range n.children {
+		b.WriteString(c.text())
+	}
+	return b.String()
+}
+
+// htmlBlockTags are elements rendered as separate markdown blocks
+var htmlBlockTags = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true, "body": true, "html": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "pre": true, "blockquote": true, "table": true, "hr": true,
+	"head": true, "script": true, "style": true,
+}
+
+// htmlMarkdown renders HTML nodes as markdown blocks; consecutive inline nodes
+// become a paragraph
+func htmlMarkdown(nodes []*htmlNode) string {
+	var out []string
+	var inline strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(inline.String()); s != "" {
+			out = append(out, strings.TrimSuffix(s, "\\"))
+		}
+		inline.Reset()
+	}
+	for _, n := range nodes {
+		if !htmlBlockTags[n.tag] {
+			inline.WriteString(htmlInline(n))
+			continue
+		}
+		flush()
+		if s := htmlBlock(n); s != "" {
+			out = append(out, s)
+		}
+	}
+	flush()
+	return strings.Join(out, "\n\n")
+}
+
+func htmlBlock(n *htmlNode) string {
+	switch n.tag {
+	case "head", "script", "style":
+		return ""
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := strings.TrimSpace(htmlInlines(n.children))
+		if text == "" {
+			return ""
+		}
+		return strings.Repeat("#", int(n.tag[1]-'0')) + " " + strings.ReplaceAll(text, "\\\n", " ")
+	case "hr":
+		return "---"
+	case "pre":
+		lang := ""
+		if m := brushRe.FindStringSubmatch(n.attr["data-syntaxhighlighter-params"]); m != nil {
+			lang = m[1]
+		}
+		text := strings.Trim(n.text(), "\n")
+		fence := "```"
+		for strings.Contains(text, fence) {
+			fence += "`"
+		}
+		return fence + lang + "\n" + text + "\n" + fence
+	case "blockquote":
+		return quoteMarkdown(htmlMarkdown(n.children))
+	case "ul", "ol":
+		var items []string
+		for _, li := range n.children {
+			if li.tag != "li" {
+				continue
+			}
+			marker := "- "
+			if n.tag == "ol" {
+				marker = strconv.Itoa(len(items)+1) + ". "
+			}
+			indent := strings.Repeat(" ", len(marker))
+			// nested lists follow the item text without a blank line,
+			// keeping the list tight
+			text := nestedListRe.ReplaceAllString(htmlMarkdown(li.children), "\n$1")
+			lines := strings.Split(text, "\n")
+			for i := 1; i < len(lines); i++ {
+				if lines[i] != "" {
+					lines[i] = indent + lines[i]
+				}
+			}
+			items = append(items, marker+strings.Join(lines, "\n"))
+		}
+		return strings.Join(items, "\n")
+	case "table":
+		var rows [][]string
+		var width int
+		for tr := range n.all(func(x *htmlNode) bool { return x.tag == "tr" }) {
+			var row []string
+			for _, c := range tr.children {
+				if c.tag != "th" && c.tag != "td" {
+					continue
+				}
+				s := strings.TrimSpace(htmlInlines(c.children))
+				s = strings.ReplaceAll(strings.ReplaceAll(s, "\\\n", " "), "|", "\\|")
+				row = append(row, s)
+			}
+			width = max(width, len(row))
+			rows = append(rows, row)
+		}
+		if width == 0 {
+			return ""
+		}
+		var b strings.Builder
+		for i, row := range rows {
+			for len(row) < width {
+				row = append(row, "")
+			}
+			b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+			if i == 0 {
+				b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+			}
+		}
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+	// Confluence info, note, and warning panels
+	if n.hasClass("confluence-information-macro") {
+		return quoteMarkdown(htmlMarkdown(n.children))
+	}
+	return htmlMarkdown(n.children)
+}
+
+var nestedListRe = regexp.MustCompile(`\n\n((?:- |\d+\. ).*)`)
+
+var brushRe = regexp.MustCompile(`brush:\s*([\w+-]+)`)
+
+func quoteMarkdown(s string) string {
+	if s == "" {
+		return ""
+	}
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight("> "+l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func htmlInlines(nodes []*htmlNode) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(htmlInline(n))
+	}
+	return b.String()
+}
+
+func htmlInline(n *htmlNode) string {
+	// wrap surrounds inner text with the delimiter, keeping the whitespace
+	// around the text outside, as emphasis must not start or end with spaces
+	wrap := func(delim string) string {
+		s := htmlInlines(n.children)
+		inner := strings.TrimSpace(s)
+		if inner == "" {
+			return s
+		}
+		i := strings.Index(s, inner)
+		return s[:i] + delim + inner + delim + s[i+len(inner):]
+	}
+	switch n.tag {
+	case "":
+		return escapeMarkdownText(collapseSpace(n.data))
+	case "strong", "b":
+		return wrap("**")
+	case "em", "i":
+		return wrap("*")
+	case "del", "s", "strike":
+		return wrap("~~")
+	case "code", "tt":
+		s := collapseSpace(n.text())
+		if strings.TrimSpace(s) == "" {
+			return s
+		}
+		fence := "`"
+		for strings.Contains(s, fence) {
+			fence += "`"
+		}
+		return fence + s + fence
+	case "br":
+		return "\\\n"
+	case "img":
+		src := n.attr["src"]
+		if src == "" {
+			return ""
+		}
+		return "![" + escapeLinkText(n.attr["alt"]) + "](" + markdownURL(src) + ")"
+	case "a":
+		s := htmlInlines(n.children)
+		href := n.attr["href"]
+		if href == "" || strings.TrimSpace(s) == "" {
+			return s
+		}
+		return "[" + strings.TrimSpace(s) + "](" + markdownURL(href) + ")"
+	case "script", "style":
+		return ""
+	}
+	return htmlInlines(n.children)
+}
+
+// markdownURL returns link destination safe to use in markdown link
+func markdownURL(s string) string {
+	if strings.ContainsAny(s, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(s) + ">"
+	}
+	return s
+}
+
+// collapseSpace replaces runs of whitespace with a single space, as HTML
+// renders them
+func collapseSpace(s string) string {
+	return spaceRe.ReplaceAllString(s, " ")
+}
+
+var spaceRe = regexp.MustCompile(`\s+`)
+
+func escapeMarkdownText(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `<`, `\<`,
+)
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestHTMLMarkdown(t *testing.T) {
+	for _, tc := range []struct {
+		html, want string
+	}{
+		{"<p>Hello <b>bold</b> and<i> spaced </i>text</p>", "Hello **bold** and *spaced* text"},
+		{"<p>a * b _c_ [d]</p>", `a \* b \_c\_ \[d\]`},
+		{"<h2>Title<br/>continued</h2><p>x</p>", "## Title continued\n\nx"},
+		{"<p>line<br/>break</p>", "line\\\nbreak"},
+		{"<p>use <code>a`b</code></p>", "use ``a`b``"},
+		{`<p><a href="https://example.com/a b">link</a> <img src="x.png" alt="[alt]"/></p>`,
+			"[link](<https://example.com/a b>) ![\\[alt\\]](x.png)"},
+		{"<ul><li>one</li><li>two<ul><li>nested</li></ul></li></ul>", "- one\n- two\n  - nested"},
+		{"<ol><li>first</li><li>second</li></ol>", "1. first\n2. second"},
+		{"<table><tr><th>A</th><th>B</th></tr><tr><td>1|2</td></tr></table>", "| A | B |\n| --- | --- |\n| 1\\|2 |  |"},
+		{`<pre data-syntaxhighlighter-params="brush: go; gutter: false">x := "` + "```" + `"</pre>`, "````go\nx := \"```\"\n````"},
+		{"<blockquote><p>quoted</p><p>twice</p></blockquote>", "> quoted\n>\n> twice"},
+		{`<div class="confluence-information-macro"><p>note</p></div>`, "> note"},
+		{"<p>before</p><hr/>inline <em>tail</em><script>x()</script>", "before\n\n---\n\ninline *tail*"},
+		{"<p>a &amp; b&nbsp;c</p>", "a & b\u00a0c"},
+	} {
+		if got := htmlMarkdown(parseHTML([]byte(tc.html)).children); got != tc.want {
+			t.Errorf("htmlMarkdown(%q) =\n%q\nwant\n%q", tc.html, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"rsc.io/markdown"
+)
+
+func handleConvert(ctx context.Context, _ authToken, cliargs []string) error {
+	to := "outline"
+	var dstFile, titleFlag string
+	var opts transformOptions
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s convert [-to outline|github] [flags] source-document.md\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nConverts markdown without talking to the server, reading stdin if the file is \"-\".\n"+
+			"With -to outline, the file is transformed the same way update does it: front matter and the leading\n"+
+			"H1 heading are dropped, and links to headings are rewritten to Outline style. The result is the text\n"+
+			"that would be sent as the document body; with -json flag, it is printed along with the title.\n"+
+			"With -to github, the file is treated as the Outline document text and transformed the same way get\n"+
+			"does it: links to headings are rewritten to github style, and the title, if set, is added as H1.")
+	}
+	fs.StringVar(&to, "to", to, "target `style`: outline or github")
+	fs.StringVar(&dstFile, "o", dstFile, "write result to this `file` instead of stdout")
+	fs.StringVar(&titleFlag, "title", titleFlag, "document `title`; with -to outline, overrides the one taken from the file")
+	fs.BoolVar(&opts.numberHeadings, "number-headings", opts.numberHeadings, "with -to outline, prefix headings with hierarchical section numbers;\n"+
+		"with -to github, strip such numbers")
+	fs.BoolVar(&opts.keepH1, "keep-h1", opts.keepH1, "with -to outline, keep the leading H1 heading in the document text")
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != 1 {
+		return errors.New("want source document file as the only positional argument")
+	}
+	switch to {
+	case "outline":
+		title, doc, _, err := readDocument(args[0], opts)
+		if err != nil {
+			return err
+		}
+		if titleFlag != "" {
+			title = titleFlag
+		}
+		if jsonOutput {
+			return writeJSON(dstFile, struct {
+				Title string `json:"title"`
+				Text  string `json:"text"`
+			}{title, markdown.Format(doc)})
+		}
+		return writeResult(dstFile, []byte(markdown.Format(doc)))
+	case "github":
+		var data []byte
+		var err error
+		if args[0] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return err
+		}
+		var p markdown.Parser
+		doc := p.Parse(string(data))
+		if opts.numberHeadings {
+			stripHeadingNumbers(doc)
+		}
+		restoreHeadingLinks(doc)
+		text := markdown.Format(doc)
+		if titleFlag == "" {
+			return writeResult(dstFile, []byte(text))
+		}
+		return writeResult(dstFile, documentMarkdown(titleFlag, text))
+	}
+	return fmt.Errorf("invalid -to value %q, want outline or github", to)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// debugHTTP makes doRetry dump every request and response to stderr. It is
+// set with the global -debug flag or the OUTLINE_DEBUG=1 environment
+// variable.
+var debugHTTP bool
+
+// maxDebugBody is how much of a request or response body is dumped
+const maxDebugBody = 1 << 20
+
+var debugMu sync.Mutex // serializes dumps of concurrent requests
+
+// debugRequest dumps request line, headers and body to stderr. Credentials
+// are redacted from headers and the body.
+func debugRequest(req *http.Request) {
+	var body []byte
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			body, _ = io.ReadAll(io.LimitReader(rc, maxDebugBody+1))
+			rc.Close()
+		}
+	}
+	secret := bearerToken(req.Header)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "> %s %s\n", req.Method, req.URL)
+	writeDebugHeaders(&b, "> ", req.Header, secret)
+	writeDebugBody(&b, req.Header.Get("Content-Type"), body, req.ContentLength, secret)
+	debugWrite(b.Bytes())
+}
+
+// debugResponse dumps response status, headers and body to stderr, the
+// body is only dumped if it is text. Token of the request the response is
+// for is redacted from the body.
+func debugResponse(resp *http.Response) {
+	var body []byte
+	if isTextType(resp.Header.Get("Content-Type")) {
+		// the part read is put back in front of the rest of the body
+		body, _ = io.ReadAll(io.LimitReader(resp.Body, maxDebugBody+1))
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	}
+	var secret string
+	if resp.Request != nil {
+		secret = bearerToken(resp.Request.Header)
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "< %s %s\n", resp.Proto, resp.Status)
+	writeDebugHeaders(&b, "< ", resp.Header, secret)
+	writeDebugBody(&b, resp.Header.Get("Content-Type"), body, resp.ContentLength, secret)
+	debugWrite(b.Bytes())
+}
+
+func debugWrite(data []byte) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	os.Stderr.Write(data)
+}
+
+// sensitiveHeaders have their values redacted in dumps, along with the
+// headers set with the OUTLINE_HEADERS environment variable
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+func writeDebugHeaders(w io.Writer, prefix string, h http.Header, secret string) {
+	for _, k := range slices.Sorted(maps.Keys(h)) {
+		for _, v := range h[k] {
+			switch {
+			case k == "Authorization" && strings.HasPrefix(v, "Bearer "):
+				v = "Bearer [REDACTED]"
+			case slices.Contains(sensitiveHeaders, k), extraHeaders.Get(k) != "":
+				v = "[REDACTED]"
+			case secret != "":
+				v = strings.ReplaceAll(v, secret, "[REDACTED]")
+			}
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, k, v)
+		}
+	}
+}
+
+func writeDebugBody(b *bytes.Buffer, contentType string, body []byte, size int64, secret string) {
+	switch {
+	case size == 0 || body == nil && size < 0:
+		b.WriteString("\n")
+		return
+	case body == nil || !isTextType(contentType):
+		fmt.Fprintf(b, "\n[%d bytes of %s]\n\n", max(size, int64(len(body))), cmp.Or(contentType, "unknown type"))
+		return
+	}
+	truncated := len(body) > maxDebugBody
+	if truncated {
+		body = body[:maxDebugBody]
+	}
+	if secret != "" {
+		body = bytes.ReplaceAll(body, []byte(secret), []byte("[REDACTED]"))
+	}
+	b.WriteString("\n")
+	b.Write(body)
+	if len(body) != 0 && body[len(body)-1] != '\n' {
+		b.WriteString("\n")
+	}
+	if truncated {
+		fmt.Fprintf(b, "[truncated to %d bytes]\n", maxDebugBody)
+	}
+	b.WriteString("\n")
+}
+
+// isTextType reports whether content of the mime type is human-readable
+func isTextType(contentType string) bool {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(t, "text/") || t == "application/json" || strings.HasSuffix(t, "+json") ||
+		t == "application/x-www-form-urlencoded"
+}
+
+// bearerToken returns the token of the Authorization header, or an empty
+// string if there is none
+func bearerToken(h http.Header) string {
+	s, _ := strings.CutPrefix(h.Get("Authorization"), "Bearer ")
+	return s
+}
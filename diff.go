@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// diffOp is a single step of the edit script turning one sequence into
+// another
+type diffOp struct {
+	kind byte // ' ' for equal, '-' for deletion, '+' for insertion
+	a, b int  // indexes in old and new sequences; for insertions and deletions, index in the other sequence is where the change happens
+}
+
+// diffSlices returns the shortest edit script turning a into b, computed with
+// the linear space variant of the Myers' algorithm. In every run of changes
+// deletions come before insertions.
+func diffSlices[T comparable](a, b []T) []diffOp {
+	var ops []diffOp
+	diffRange(a, b, 0, 0, &ops)
+	// subproblems may interleave deletions and insertions, regroup them
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		j, dels := i, 0
+		for ; j < len(ops) && ops[j].kind != ' '; j++ {
+			if ops[j].kind == '-' {
+				dels++
+			}
+		}
+		a0, b0 := ops[i].a, ops[i].b
+		for n := i; n < j; n++ {
+			if n-i < dels {
+				ops[n] = diffOp{kind: '-', a: a0 + n - i, b: b0}
+			} else {
+				ops[n] = diffOp{kind: '+', a: a0 + dels, b: b0 + n - i - dels}
+			}
+		}
+		i = j
+	}
+	return ops
+}
+
+// diffRange appends to ops the edit script turning a into b, which are
+// subslices starting at indexes aOff and bOff of the original sequences. It
+// splits the problem on the middle snake of the shortest edit path and
+// recurses on both halves, so memory use stays linear.
+func diffRange[T comparable](a, b []T, aOff, bOff int, ops *[]diffOp) {
+	for len(a) != 0 && len(b) != 0 && a[0] == b[0] {
+		*ops = append(*ops, diffOp{kind: ' ', a: aOff, b: bOff})
+		a, b, aOff, bOff = a[1:], b[1:], aOff+1, bOff+1
+	}
+	var suffix int
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+	switch {
+	case len(a) == 0:
+		for i := range b {
+			*ops = append(*ops, diffOp{kind: '+', a: aOff, b: bOff + i})
+		}
+	case len(b) == 0:
+		for i := range a {
+			*ops = append(*ops, diffOp{kind: '-', a: aOff + i, b: bOff})
+		}
+	default:
+		x, y, u, v := middleSnake(a, b)
+		diffRange(a[:x], b[:y], aOff, bOff, ops)
+		for i := range u - x {
+			*ops = append(*ops, diffOp{kind: ' ', a: aOff + x + i, b: bOff + y + i})
+		}
+		diffRange(a[u:], b[v:], aOff+u, bOff+v, ops)
+	}
+	for i := range suffix {
+		*ops = append(*ops, diffOp{kind: ' ', a: aOff + len(a) + i, b: bOff + len(b) + i})
+	}
+}
+
+// middleSnake returns the start (x, y) and the end (u, v) of the snake in
+// the middle of the shortest edit path turning a into b, found by running the
+// Myers' algorithm from both ends until the paths overlap.
+func middleSnake[T comparable](a, b []T) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// furthest reaching x on each diagonal k, from the start and, in
+	// reversed sequences, from the end
+	vf := make([]int, 2*offset+1)
+	vb := make([]int, 2*offset+1)
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u, v = u+1, v+1
+			}
+			vf[offset+k] = u
+			if odd && k >= delta-(d-1) && k <= delta+(d-1) && u+vb[offset+delta-k] >= n {
+				return x, y, u, v
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var bx int
+			if k == -d || (k != d && vb[offset+k-1] < vb[offset+k+1]) {
+				bx = vb[offset+k+1]
+			} else {
+				bx = vb[offset+k-1] + 1
+			}
+			by := bx - k
+			ex, ey := bx, by
+			for ex < n && ey < m && a[n-1-ex] == b[m-1-ey] {
+				ex, ey = ex+1, ey+1
+			}
+			vb[offset+k] = ex
+			if !odd && k >= delta-d && k <= delta+d && ex+vf[offset+delta-k] >= n {
+				return n - ex, m - ey, n - bx, m - by
+			}
+		}
+	}
+	panic("diff: no middle snake") // unreachable, paths always overlap
+}
+
+// textDiff holds settings for rendering unified diffs
+type textDiff struct {
+	context int  // number of unchanged lines to show around changes
+	color   bool // colorize output with ANSI escape sequences, highlighting changed words
+}
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+	ansiHiOn  = "\x1b[7m"  // reverse video
+	ansiHiOff = "\x1b[27m" // reverse video off
+)
+
+// write writes unified diff of old and new texts to w, and reports whether
+// texts differ.
+func (td textDiff) write(w io.Writer, oldName, newName, oldText, newText string) bool {
+	a, b := splitLines(oldText), splitLines(newText)
+	ops := diffSlices(a, b)
+	if !slices.ContainsFunc(ops, func(op diffOp) bool { return op.kind != ' ' }) {
+		return false
+	}
+	td.printf(w, ansiBold, "--- %s\n", oldName)
+	td.printf(w, ansiBold, "+++ %s\n", newName)
+	for _, h := range hunks(ops, td.context) {
+		var aStart, aLen, bStart, bLen int
+		aStart, bStart = -1, -1
+		for _, op := range h {
+			switch op.kind {
+			case ' ':
+				aLen++
+				bLen++
+			case '-':
+				aLen++
+			case '+':
+				bLen++
+			}
+			if aStart == -1 && op.kind != '+' {
+				aStart = op.a
+			}
+			if bStart == -1 && op.kind != '-' {
+				bStart = op.b
+			}
+		}
+		if aStart == -1 {
+			aStart = h[0].a // pure insertion
+		}
+		if bStart == -1 {
+			bStart = h[0].b
+		}
+		td.printf(w, ansiCyan, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+		for i := 0; i < len(h); {
+			if h[i].kind == ' ' {
+				fmt.Fprintf(w, " %s\n", a[h[i].a])
+				i++
+				continue
+			}
+			// collect a run of deletions followed by insertions
+			j := i
+			for j < len(h) && h[j].kind == '-' {
+				j++
+			}
+			k := j
+			for k < len(h) && h[k].kind == '+' {
+				k++
+			}
+			dels, ins := h[i:j], h[j:k]
+			if td.color && len(dels) == len(ins) {
+				// pair modified lines to highlight changed words
+				newLines := make([]string, len(ins))
+				for n := range dels {
+					var oldLine string
+					oldLine, newLines[n] = highlightWords(a[dels[n].a], b[ins[n].b])
+					fmt.Fprintf(w, "%s-%s%s\n", ansiRed, oldLine, ansiReset)
+				}
+				for _, s := range newLines {
+					fmt.Fprintf(w, "%s+%s%s\n", ansiGreen, s, ansiReset)
+				}
+			} else {
+				for _, op := range dels {
+					td.printf(w, ansiRed, "-%s\n", a[op.a])
+				}
+				for _, op := range ins {
+					td.printf(w, ansiGreen, "+%s\n", b[op.b])
+				}
+			}
+			i = k
+		}
+	}
+	return true
+}
+
+func (td textDiff) printf(w io.Writer, color, format string, args ...any) {
+	if !td.color {
+		fmt.Fprintf(w, format, args...)
+		return
+	}
+	s := fmt.Sprintf(format, args...)
+	fmt.Fprint(w, color, strings.TrimSuffix(s, "\n"), ansiReset, "\n")
+}
+
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// hunks splits edit script into groups of changes surrounded by at most
+// context unchanged elements
+func hunks(ops []diffOp, context int) [][]diffOp {
+	var out [][]diffOp
+	var cur []diffOp
+	lastChange := -1
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		start := max(i-context, 0)
+		if cur != nil && start <= lastChange+context+1 {
+			cur = append(cur, ops[lastChange+1:i+1]...)
+		} else {
+			if cur != nil {
+				out = append(out, append(cur, ops[lastChange+1:min(lastChange+1+context, len(ops))]...))
+			}
+			cur = slices.Clone(ops[start : i+1])
+		}
+		lastChange = i
+	}
+	if cur != nil {
+		out = append(out, append(cur, ops[lastChange+1:min(lastChange+1+context, len(ops))]...))
+	}
+	return out
+}
+
+// highlightWords returns both lines with the changed words highlighted
+func highlightWords(oldLine, newLine string) (string, string) {
+	a, b := splitWords(oldLine), splitWords(newLine)
+	var oldOut, newOut strings.Builder
+	for _, op := range diffSlices(a, b) {
+		switch op.kind {
+		case ' ':
+			oldOut.WriteString(a[op.a])
+			newOut.WriteString(b[op.b])
+		case '-':
+			oldOut.WriteString(ansiHiOn + a[op.a] + ansiHiOff)
+		case '+':
+			newOut.WriteString(ansiHiOn + b[op.b] + ansiHiOff)
+		}
+	}
+	return oldOut.String(), newOut.String()
+}
+
+// splitWords splits s into runs of letters and digits, runs of spaces, and
+// individual other characters
+func splitWords(s string) []string {
+	var out []string
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		}
+		return 0
+	}
+	start, prev := 0, -1
+	for i, r := range s {
+		c := class(r)
+		if i != 0 && (c != prev || c == 0) {
+			out = append(out, s[start:i])
+			start = i
+		}
+		prev = c
+	}
+	if start < len(s) {
+		out = append(out, s[start:])
+	}
+	return out
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// useColor reports whether output to f should be colorized, mode is one of
+// "auto", "always", "never"
+func useColor(mode string, f *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto", "":
+		if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+			return false, nil
+		}
+		st, err := f.Stat()
+		return err == nil && st.Mode()&os.ModeCharDevice != 0, nil
+	}
+	return false, fmt.Errorf("invalid color mode %q, want auto, always, or never", mode)
+}
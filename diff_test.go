@@ -0,0 +1,83 @@
+package main
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestDiffSlicesLarge(t *testing.T) {
+	// fully different inputs make the edit path as long as it gets
+	const n = 4000
+	a, b := make([]string, n), make([]string, n)
+	for i := range n {
+		a[i] = "old line " + strconv.Itoa(i)
+		b[i] = "new line " + strconv.Itoa(i)
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	ops := diffSlices(a, b)
+	runtime.ReadMemStats(&after)
+	if len(ops) != 2*n {
+		t.Fatalf("got %d ops, want %d", len(ops), 2*n)
+	}
+	for i, op := range ops {
+		want := byte('-')
+		if i >= n {
+			want = '+'
+		}
+		if op.kind != want {
+			t.Fatalf("op %d is %q, want %q", i, op.kind, want)
+		}
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 16<<20 {
+		t.Fatalf("diff allocated %d bytes, want memory linear in input size", alloc)
+	}
+}
+
+func TestDiffSlices(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want string // op kinds of the edit script
+	}{
+		{"", "", ""},
+		{"abc", "abc", "   "},
+		{"", "ab", "++"},
+		{"ab", "", "--"},
+		{"abc", "axc", " -+ "},
+		{"abcd", "acd", " -  "},
+		{"abc", "abxc", "  + "},
+		{"abcabba", "cbabac", "-+ -  - +"},
+		{"xyz", "abc", "---+++"},
+	} {
+		a, b := []byte(tc.a), []byte(tc.b)
+		ops := diffSlices(a, b)
+		var kinds []byte
+		var out []byte // b rebuilt from a by the edit script
+		var ai int
+		for _, op := range ops {
+			kinds = append(kinds, op.kind)
+			switch op.kind {
+			case ' ':
+				if op.a != ai || a[op.a] != b[op.b] {
+					t.Fatalf("diffSlices(%q, %q): bad op %+v", tc.a, tc.b, op)
+				}
+				out = append(out, a[op.a])
+				ai++
+			case '-':
+				if op.a != ai {
+					t.Fatalf("diffSlices(%q, %q): bad op %+v", tc.a, tc.b, op)
+				}
+				ai++
+			case '+':
+				out = append(out, b[op.b])
+			}
+		}
+		if string(kinds) != tc.want {
+			t.Errorf("diffSlices(%q, %q) = %q, want %q", tc.a, tc.b, kinds, tc.want)
+		}
+		if ai != len(a) || string(out) != tc.b {
+			t.Errorf("diffSlices(%q, %q) script produces %q", tc.a, tc.b, out)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func handleDelete(ctx context.Context, token authToken, cliargs []string) error {
+	var permanent, yes bool
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s delete [flags] url|urlid\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.BoolVar(&permanent, "permanent", permanent, "delete document permanently instead of moving it to trash")
+	fs.BoolVar(&yes, "yes", yes, "do not ask for confirmation")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url or id")
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	if !yes {
+		d, err := documentInfo(ctx, token, urlid)
+		if err != nil {
+			return err
+		}
+		prompt := fmt.Sprintf("Move document %q to trash?", d.Title)
+		if permanent {
+			prompt = fmt.Sprintf("Permanently delete document %q?", d.Title)
+		}
+		if ok, err := confirm(prompt); err != nil || !ok {
+			return err
+		}
+	}
+	req := struct {
+		Id        string `json:"id"`
+		Permanent bool   `json:"permanent,omitempty"`
+	}{Id: urlid, Permanent: permanent}
+	return apiCall(ctx, token, apiURL("documents.delete"), req)
+}
+
+func handleArchive(ctx context.Context, token authToken, cliargs []string) error {
+	return docLifecycleCmd(ctx, token, cliargs, "archive", apiURL("documents.archive"))
+}
+
+func handleUnarchive(ctx context.Context, token authToken, cliargs []string) error {
+	return docLifecycleCmd(ctx, token, cliargs, "unarchive", apiURL("documents.restore"))
+}
+
+// docLifecycleCmd implements subcommands that take a single document
+// url|urlid argument and call an endpoint that only needs document id.
+func docLifecycleCmd(ctx context.Context, token authToken, cliargs []string, name, endpoint string) error {
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags] url|urlid\n", exeName, name)
+		fs.PrintDefaults()
+	}
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url or id")
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	req := struct {
+		Id string `json:"id"`
+	}{Id: urlid}
+	return apiCall(ctx, token, endpoint, req)
+}
+
+func handleUnpublish(ctx context.Context, token authToken, cliargs []string) error {
+	return docLifecycleCmd(ctx, token, cliargs, "unpublish", apiURL("documents.unpublish"))
+}
+
+func handlePublish(ctx context.Context, token authToken, cliargs []string) error {
+	var collection string
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s publish [flags] url|urlid\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&collection, "collection", collection, "id or name of the collection to publish document to, only needed for drafts without one")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url or id")
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	if collection, err = collectionID(ctx, token, collection); err != nil {
+		return err
+	}
+	// documentUpdate always sends text, so it cannot be used here
+	req := struct {
+		Id         string `json:"id"`
+		Publish    bool   `json:"publish"`
+		Collection string `json:"collectionId,omitempty"`
+	}{Id: urlid, Publish: true, Collection: collection}
+	return apiCall(ctx, token, apiURL("documents.update"), req)
+}
+
+func handleMove(ctx context.Context, token authToken, cliargs []string) error {
+	var collection, parent string
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s move url|urlid -collection id [-parent url|urlid]\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&collection, "collection", collection, "id or name of the collection to move document to")
+	fs.StringVar(&parent, "parent", parent, "parent document url|urlid, if not set, document is moved to the collection root")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url or id")
+	}
+	if collection == "" {
+		return errors.New("-collection flag must be set")
+	}
+	if parent != "" {
+		parent = documentID(parent)
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	if collection, err = collectionID(ctx, token, collection); err != nil {
+		return err
+	}
+	req := struct {
+		Id         string `json:"id"`
+		Collection string `json:"collectionId"`
+		Parent     string `json:"parentDocumentId,omitempty"`
+	}{Id: urlid, Collection: collection, Parent: parent}
+	return apiCall(ctx, token, apiURL("documents.move"), req)
+}
+
+// newDocument holds attributes of a document to be created
+type newDocument struct {
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+	Collection string `json:"collectionId,omitempty"`
+	Parent     string `json:"parentDocumentId,omitempty"`
+	Publish    bool   `json:"publish"`
+	Template   bool   `json:"template,omitempty"`
+	TemplateID string `json:"templateId,omitempty"`
+	Icon       string `json:"icon,omitempty"`
+	FullWidth  bool   `json:"fullWidth,omitempty"`
+}
+
+func createDocument(ctx context.Context, token authToken, nd newDocument) (*document, error) {
+	return apiRequest[*document](ctx, token, apiURL("documents.create"), nd)
+}
+
+// documentUpdate holds document attributes to change with documents.update
+type documentUpdate struct {
+	Id    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text"`
+	// optional attributes are only changed when set
+	Icon      *string `json:"icon,omitempty"`
+	FullWidth *bool   `json:"fullWidth,omitempty"`
+	Publish   bool    `json:"publish,omitempty"`
+	Append    bool    `json:"append,omitempty"` // append text to the document instead of replacing it
+}
+
+func updateDocument(ctx context.Context, token authToken, du documentUpdate) (*document, error) {
+	return apiRequest[*document](ctx, token, apiURL("documents.update"), du)
+}
+
+// confirm asks user a yes/no question on stderr and reads the answer from
+// stdin, returning true only if the answer is positive.
+func confirm(prompt string) (bool, error) {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", prompt)
+	sc := bufio.NewScanner(os.Stdin)
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return false, err
+		}
+		return false, errors.New("no confirmation received, use -yes flag to skip it")
+	}
+	switch strings.ToLower(strings.TrimSpace(sc.Text())) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+func handleTemplatize(ctx context.Context, token authToken, cliargs []string) error {
+	d, err := docArgument(ctx, token, "templatize", cliargs)
+	if err != nil {
+		return err
+	}
+	req := struct {
+		Id string `json:"id"`
+	}{Id: d.ID}
+	res, err := apiRequest[document](ctx, token, apiURL("documents.templatize"), req)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return writeJSON("", summarize(&res))
+	}
+	fmt.Printf("%s\t%s\n", res.ID, webURL(res.URL))
+	return nil
+}
+
+func handleDuplicate(ctx context.Context, token authToken, cliargs []string) error {
+	var collection, title string
+	var recursive bool
+	loc := docLocator{titleFlag: "find-title"}
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s duplicate [flags] url|urlid\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&collection, "collection", collection, "id or name of the collection to create the copy in, if not set, it is created next to the original")
+	fs.StringVar(&title, "title", title, "`title` of the copy, if not set, Outline picks one based on the original title")
+	fs.BoolVar(&recursive, "recursive", recursive, "also duplicate all nested child documents")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url or id")
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	d, err := documentInfo(ctx, token, urlid)
+	if err != nil {
+		return err
+	}
+	if collection, err = collectionID(ctx, token, collection); err != nil {
+		return err
+	}
+	req := struct {
+		Id         string `json:"id"`
+		Title      string `json:"title,omitempty"`
+		Recursive  bool   `json:"recursive"`
+		Publish    bool   `json:"publish"`
+		Collection string `json:"collectionId,omitempty"`
+	}{Id: d.ID, Title: title, Recursive: recursive, Publish: d.PublishedAt != nil, Collection: collection}
+	res, err := apiRequest[struct {
+		Documents []document `json:"documents"`
+	}](ctx, token, apiURL("documents.duplicate"), req)
+	if err != nil {
+		return err
+	}
+	if len(res.Documents) == 0 {
+		return errors.New("no documents were created")
+	}
+	// the copy of the document itself comes first, followed by copies of
+	// its children
+	c := &res.Documents[0]
+	if jsonOutput {
+		return writeJSON("", summarize(c))
+	}
+	fmt.Printf("%s\t%s\n", c.ID, webURL(c.URL))
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"slices"
+	"time"
+)
+
+func handleEvents(ctx context.Context, token authToken, cliargs []string) error {
+	var dstFile, actor, document, collection, name string
+	var since time.Time
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s events [flags]\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nEvents are printed as newline-delimited JSON objects, oldest first.")
+	}
+	fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+	fs.Func("since", "only list events that happened at or after this `time`, as 2006-01-02 or RFC 3339", func(s string) error {
+		var err error
+		if since, err = time.Parse(time.DateOnly, s); err != nil {
+			since, err = time.Parse(time.RFC3339, s)
+		}
+		return err
+	})
+	fs.StringVar(&actor, "actor", actor, "only list events of the user with this id")
+	fs.StringVar(&document, "document", document, "only list events related to the document url|urlid")
+	fs.StringVar(&collection, "collection", collection, "only list events related to the collection with this id or name")
+	fs.StringVar(&name, "name", name, "only list events with this name, like documents.update")
+	fs.Parse(cliargs)
+	collection, err := collectionID(ctx, token, collection)
+	if err != nil {
+		return err
+	}
+	if document != "" {
+		d, err := documentInfo(ctx, token, documentID(document))
+		if err != nil {
+			return err
+		}
+		document = d.ID
+	}
+	events := paginate[json.RawMessage](ctx, token, apiURL("events.list"), 0, func(p pagination) any {
+		return struct {
+			pagination
+			AuditLog   bool   `json:"auditLog"`
+			Actor      string `json:"actorId,omitempty"`
+			Document   string `json:"documentId,omitempty"`
+			Collection string `json:"collectionId,omitempty"`
+			Name       string `json:"name,omitempty"`
+			Sort       string `json:"sort"`
+			Direction  string `json:"direction"`
+		}{pagination: p, AuditLog: true, Actor: actor, Document: document, Collection: collection, Name: name,
+			Sort: "createdAt", Direction: "DESC"}
+	})
+	// events are requested newest first, so listing stops at the first event
+	// older than -since
+	var out []json.RawMessage
+	for raw, err := range events {
+		if err != nil {
+			return err
+		}
+		var ev struct {
+			CreatedAt time.Time `json:"createdAt"`
+		}
+		if err := json.Unmarshal(raw, &ev); err != nil {
+			return err
+		}
+		if ev.CreatedAt.Before(since) {
+			break
+		}
+		out = append(out, raw)
+	}
+	slices.Reverse(out)
+	var buf bytes.Buffer
+	for _, raw := range out {
+		if err := json.Compact(&buf, raw); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+	}
+	return writeResult(dstFile, buf.Bytes())
+}
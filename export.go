@@ -0,0 +1,224 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+func handleExport(ctx context.Context, token authToken, cliargs []string) error {
+	collection := defaultCollection
+	format := "markdown"
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s export -collection id [flags] out.zip\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&collection, "collection", collection, "id or name of the collection to export")
+	fs.StringVar(&format, "format", format, "export format: markdown, json, or html")
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != 1 {
+		return errors.New("want destination file as the only positional argument")
+	}
+	if collection == "" {
+		return errors.New("-collection flag must be set")
+	}
+	apiFormat, err := exportFormat(format)
+	if err != nil {
+		return err
+	}
+	if collection, err = collectionID(ctx, token, collection); err != nil {
+		return err
+	}
+	req := struct {
+		Id     string `json:"id"`
+		Format string `json:"format"`
+	}{Id: collection, Format: apiFormat}
+	res, err := apiRequest[struct {
+		FileOperation fileOperation `json:"fileOperation"`
+	}](ctx, token, apiURL("collections.export"), req)
+	if err != nil {
+		return err
+	}
+	op, err := waitFileOperation(ctx, token, &res.FileOperation)
+	if err != nil {
+		return err
+	}
+	return downloadFileOperation(ctx, token, op, args[0])
+}
+
+// exportFormat maps export format name used by the command line flags to the
+// API one
+func exportFormat(s string) (string, error) {
+	switch s {
+	case "markdown":
+		return "outline-markdown", nil
+	case "json", "html":
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid format %q, want markdown, json, or html", s)
+}
+
+// fileOperation is a server-side export or import task
+type fileOperation struct {
+	ID    string `json:"id"`
+	State string `json:"state"` // creating, uploading, complete, error, expired
+	Error string `json:"error"`
+	Size  int64  `json:"size"`
+}
+
+// waitFileOperation polls file operation state until it is complete
+func waitFileOperation(ctx context.Context, token authToken, op *fileOperation) (*fileOperation, error) {
+	const pollInterval = 3 * time.Second
+	for start := time.Now(); ; {
+		switch op.State {
+		case "complete":
+			return op, nil
+		case "error", "expired":
+			if op.Error != "" {
+				return nil, fmt.Errorf("export %s: %s", op.State, op.Error)
+			}
+			return nil, fmt.Errorf("export %s", op.State)
+		}
+		if time.Since(start) > time.Minute {
+			log.Printf("waiting for export %s to complete, current state: %s", op.ID, op.State)
+			start = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+		req := struct {
+			Id string `json:"id"`
+		}{Id: op.ID}
+		res, err := apiRequest[fileOperation](ctx, token, apiURL("fileOperations.info"), req)
+		if err != nil {
+			return nil, err
+		}
+		op = &res
+	}
+}
+
+// downloadFileOperation saves the result of a complete file operation to
+// the file
+func downloadFileOperation(ctx context.Context, token authToken, op *fileOperation, name string) error {
+	body, err := json.Marshal(struct {
+		Id string `json:"id"`
+	}{Id: op.ID})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL("fileOperations.redirect"), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	setCommonHeaders(req.Header)
+	req.Header.Set("Content-Type", "application/json")
+	// http.Client drops this header on redirects to other hosts, like
+	// presigned storage urls
+	req.Header.Set("Authorization", token.bearer())
+	resp, err := doRetry(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading export: unexpected status: %s", resp.Status)
+	}
+	return saveBody(name, resp.Body)
+}
+
+func handleBackup(ctx context.Context, token authToken, cliargs []string) error {
+	format := "markdown"
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s backup [flags] out.zip\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nAll workspace collections are exported, the archive is checked to be a valid zip file\n"+
+			"before it is saved under the destination name.")
+	}
+	fs.StringVar(&format, "format", format, "export format: markdown, json, or html")
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != 1 {
+		return errors.New("want destination file as the only positional argument")
+	}
+	apiFormat, err := exportFormat(format)
+	if err != nil {
+		return err
+	}
+	req := struct {
+		Format string `json:"format"`
+	}{Format: apiFormat}
+	res, err := apiRequest[struct {
+		FileOperation fileOperation `json:"fileOperation"`
+	}](ctx, token, apiURL("collections.export_all"), req)
+	if err != nil {
+		return err
+	}
+	op, err := waitFileOperation(ctx, token, &res.FileOperation)
+	if err != nil {
+		return err
+	}
+	dst := args[0]
+	tmp := dst + ".tmp"
+	defer os.Remove(tmp)
+	if err := downloadFileOperation(ctx, token, op, tmp); err != nil {
+		return err
+	}
+	n, err := verifyArchive(tmp, op.Size)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	log.Printf("saved %s: %d files", dst, n)
+	return nil
+}
+
+// verifyArchive checks that the file is a non-empty zip archive of the
+// expected size (if it is positive) with all its files readable and matching
+// their checksums. It returns the number of files in the archive.
+func verifyArchive(name string, size int64) (int, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return 0, fmt.Errorf("verifying %s: %w", name, err)
+	}
+	defer zr.Close()
+	if st, err := os.Stat(name); err != nil {
+		return 0, err
+	} else if size > 0 && st.Size() != size {
+		return 0, fmt.Errorf("verifying %s: size is %d bytes, want %d", name, st.Size(), size)
+	}
+	var n int
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return 0, fmt.Errorf("verifying %s: %w", name, err)
+		}
+		// zip reader checks CRC once the file is read to the end
+		_, err = io.Copy(io.Discard, rc)
+		rc.Close()
+		if err != nil {
+			return 0, fmt.Errorf("verifying %s: %s: %w", name, f.Name, err)
+		}
+		n++
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("verifying %s: archive has no files", name)
+	}
+	return n, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatter holds document metadata from the YAML block at the very
+// beginning of a markdown file, delimited by "---" lines:
+//
+//	---
+//	id: 8d1b6e1c-4a35-4a1e-9d36-1f0c5a7b5e39
+//	collection: 3a2f0f7e-98c5-4b38-86ad-0c8b5d3f6a41
+//	publish: true
+//	emoji: 📘
+//	---
+//	# Document title
+//
+// Attributes not set there are left to the command line flags.
+type frontMatter struct {
+	ID         string  `yaml:"id,omitempty"`
+	Title      string  `yaml:"title,omitempty"`
+	Collection string  `yaml:"collection,omitempty"`
+	Parent     string  `yaml:"parent,omitempty"`
+	Publish    *bool   `yaml:"publish,omitempty"`
+	Emoji      *string `yaml:"emoji,omitempty"`
+	FullWidth  *bool   `yaml:"fullWidth,omitempty"`
+}
+
+// documentFrontMatter returns front matter describing the document d
+func documentFrontMatter(d *document) *frontMatter {
+	published, fullWidth := d.PublishedAt != nil, d.FullWidth
+	fm := &frontMatter{
+		ID:         d.ID,
+		Collection: d.CollectionID,
+		Parent:     d.ParentID,
+		Publish:    &published,
+		FullWidth:  &fullWidth,
+	}
+	if icon := d.icon(); icon != "" {
+		fm.Emoji = &icon
+	}
+	return fm
+}
+
+// encode returns front matter block ready to be prepended to the document
+// text
+func (fm *frontMatter) encode() ([]byte, error) { return encodeFrontMatter(fm) }
+
+// pageFrontMatter is the front matter of documents exported for static site
+// generators like Hugo or Jekyll
+type pageFrontMatter struct {
+	Title   string    `yaml:"title"`
+	Date    time.Time `yaml:"date"`
+	Lastmod time.Time `yaml:"lastmod"`
+	Slug    string    `yaml:"slug"`
+	Weight  int       `yaml:"weight"` // 1-based position among sibling documents
+}
+
+// encodeFrontMatter returns v encoded as YAML front matter block, ready to be
+// prepended to the document text
+func encodeFrontMatter(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteString("---\n\n")
+	return buf.Bytes(), nil
+}
+
+// splitFrontMatter splits data into the YAML front matter block without its
+// delimiters and the rest of the document. If data has no front matter, meta
+// is nil.
+func splitFrontMatter(data []byte) (meta, body []byte) {
+	rest, ok := bytes.CutPrefix(data, []byte("---\n"))
+	if !ok {
+		return nil, data
+	}
+	if bytes.HasPrefix(rest, []byte("---\n")) {
+		return rest[:0], rest[4:]
+	}
+	i := bytes.Index(rest, []byte("\n---\n"))
+	if i == -1 {
+		if !bytes.HasSuffix(rest, []byte("\n---")) {
+			return nil, data
+		}
+		return rest[:len(rest)-3], nil
+	}
+	return rest[:i+1], rest[i+5:]
+}
+
+// parseFrontMatter decodes the front matter block returned by splitFrontMatter
+func parseFrontMatter(meta []byte) (*frontMatter, error) {
+	var fm frontMatter
+	if len(bytes.TrimSpace(meta)) == 0 {
+		return &fm, nil
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(meta))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fm); err != nil {
+		return nil, fmt.Errorf("parsing front matter: %w", err)
+	}
+	return &fm, nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestSplitFrontMatter(t *testing.T) {
+	for _, tc := range []struct {
+		data       string
+		meta, body string
+		hasMeta    bool
+	}{
+		{"# Title\n", "", "# Title\n", false},
+		{"---\nid: abc\n---\n# Title\n", "id: abc\n", "# Title\n", true},
+		{"---\n---\ntext\n", "", "text\n", true},
+		{"---\nid: abc\n---", "id: abc\n", "", true},
+		{"---\nid: abc\nno closing delimiter\n", "", "---\nid: abc\nno closing delimiter\n", false},
+		{"text\n---\nid: abc\n---\n", "", "text\n---\nid: abc\n---\n", false},
+		{"---\na: 1\n---\nbody\n---\nmore\n", "a: 1\n", "body\n---\nmore\n", true},
+	} {
+		meta, body := splitFrontMatter([]byte(tc.data))
+		if (meta != nil) != tc.hasMeta || string(meta) != tc.meta || string(body) != tc.body {
+			t.Errorf("splitFrontMatter(%q) = %q, %q; want %q, %q", tc.data, meta, body, tc.meta, tc.body)
+		}
+	}
+}
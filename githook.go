@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+func handleHook(ctx context.Context, token authToken, cliargs []string) error {
+	var opts transformOptions
+	var warnOnly bool
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s hook [flags] pre-push [remote [url]]\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nMeant to be called from the git pre-push hook, which gets pushed refs on stdin:\n\n"+
+			"\t#!/bin/sh\n\texec %s hook pre-push \"$@\"\n\n"+
+			"Markdown files changed by the commits being pushed are mapped to documents, either by the id\n"+
+			"in their front matter or by the %s file, and the documents are updated with the file\n"+
+			"contents as of the pushed commit. Files tracked by the manifest are only updated if their\n"+
+			"documents have not changed since the last sync, and the manifest is updated afterwards.\n"+
+			"If any document cannot be updated, the push is aborted, unless -warn is set.\n", exeName, manifestName)
+	}
+	fs.BoolVar(&opts.numberHeadings, "number-headings", opts.numberHeadings, "prefix headings with hierarchical section numbers, see the same update flag")
+	fs.BoolVar(&opts.keepH1, "keep-h1", opts.keepH1, "keep the leading H1 heading in the document text")
+	fs.BoolVar(&warnOnly, "warn", warnOnly, "do not update documents, only report files out of sync with them; never abort the push")
+	args := parseInterspersed(fs, cliargs)
+	if len(args) == 0 || args[0] != "pre-push" {
+		return errors.New("want hook name as the first positional argument, only pre-push is supported")
+	}
+	var remote string
+	if len(args) > 1 {
+		remote = args[1]
+	}
+	top, err := gitOutput(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return err
+	}
+	top = strings.TrimSpace(top)
+	type change struct{ name, rev string } // rev is the commit to take file contents from
+	var changes []change
+	seen := make(map[string]bool)
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		// <local ref> <local sha1> <remote ref> <remote sha1>
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 4 || isZeroHash(fields[1]) {
+			continue // deleted refs have nothing to push
+		}
+		names, err := pushedFiles(ctx, remote, fields[1], fields[3])
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if seen[name] || !strings.EqualFold(path.Ext(name), ".md") {
+				continue
+			}
+			seen[name] = true
+			changes = append(changes, change{name: name, rev: fields[1]})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	var failed int
+	report := func(name string, err error) {
+		failed++
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+	}
+	manifests := make(map[string]*manifest) // manifest directory to manifest
+	for _, c := range changes {
+		localName := filepath.Join(top, filepath.FromSlash(c.name))
+		data, err := gitOutput(ctx, "show", c.rev+":"+c.name)
+		if err != nil {
+			report(c.name, err)
+			continue
+		}
+		meta, _ := splitFrontMatter([]byte(data))
+		fm, err := parseFrontMatter(meta)
+		if err != nil {
+			report(c.name, err)
+			continue
+		}
+		root, m, err := findManifest(filepath.Dir(localName))
+		if err != nil {
+			return err
+		}
+		if mm, ok := manifests[root]; ok {
+			m = mm
+		} else {
+			manifests[root] = m
+		}
+		var entry *manifestEntry
+		if root != "" {
+			if rel, err := filepath.Rel(root, localName); err == nil {
+				if e, ok := m.Documents[filepath.ToSlash(rel)]; ok && e.Hash != "" {
+					entry = e
+				}
+			}
+		}
+		id := documentID(fm.ID)
+		if id == "" && entry != nil {
+			id = entry.ID
+		}
+		if id == "" {
+			continue
+		}
+		d, err := documentInfo(ctx, token, id)
+		if err != nil {
+			report(c.name, err)
+			continue
+		}
+		title, doc := parseDocument([]byte(data), opts)
+		title = cmp.Or(fm.Title, title, fileTitle(localName))
+		dir := filepath.Dir(localName)
+		ld, err := loadLocalDocs(dir)
+		if err != nil {
+			return err
+		}
+		if _, err := ld.toOutline(ctx, token, doc, dir); err != nil {
+			report(c.name, err)
+			continue
+		}
+		var p markdown.Parser
+		remoteDoc, localDoc := p.Parse(d.Text), p.Parse(markdown.Format(doc))
+		matchLocalImages(localDoc, remoteDoc)
+		if title == d.Title && markdown.Format(localDoc) == markdown.Format(remoteDoc) {
+			continue
+		}
+		if warnOnly {
+			fmt.Fprintf(os.Stderr, "%s: out of sync with %s\n", c.name, webURL(d.URL))
+			continue
+		}
+		if entry != nil && fm.ID == "" && d.UpdatedAt.After(entry.UpdatedAt) {
+			report(c.name, fmt.Errorf("document %s changed since the last sync, run sync first", webURL(d.URL)))
+			continue
+		}
+		var cache map[string]string
+		if root != "" {
+			cache = m.imageCache()
+		}
+		n := len(cache)
+		if err := uploadImages(ctx, token, doc, dir, d.ID, nil, true, cache); err != nil {
+			report(c.name, err)
+			continue
+		}
+		if root != "" && len(cache) != n {
+			if err := m.save(root); err != nil {
+				return err
+			}
+		}
+		nd, err := updateDocument(ctx, token, documentUpdate{Id: d.ID, Title: title, Text: markdown.Format(doc)})
+		if err != nil {
+			report(c.name, err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s: updated %s\n", c.name, webURL(nd.URL))
+		if entry != nil && fm.ID == "" {
+			entry.UpdatedAt, entry.Hash = nd.UpdatedAt, contentHash([]byte(data))
+			if err := m.save(root); err != nil {
+				return err
+			}
+		}
+	}
+	if failed != 0 && !warnOnly {
+		return fmt.Errorf("%d document(s) not updated", failed)
+	}
+	return nil
+}
+
+// pushedFiles returns slash-separated paths, relative to the repository top
+// level, of files added or modified by commits between the remote and local
+// commits. For new remote refs, commits not yet on the remote are considered.
+func pushedFiles(ctx context.Context, remote, local, remoteHash string) ([]string, error) {
+	var out string
+	var err error
+	if !isZeroHash(remoteHash) {
+		out, err = gitOutput(ctx, "diff", "--name-only", "--diff-filter=AMR", "-z", remoteHash, local)
+	}
+	if isZeroHash(remoteHash) || err != nil {
+		// remote commit may be unknown locally on forced pushes
+		notOn := "--remotes"
+		if remote != "" && !strings.ContainsAny(remote, "/:") { // not an url
+			notOn += "=" + remote
+		}
+		out, err = gitOutput(ctx, "log", "--name-only", "--diff-filter=AMR", "-z", "--format=", local, "--not", notOn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for s := range strings.SplitSeq(out, "\x00") {
+		if s = strings.TrimSpace(s); s != "" && !slices.Contains(names, s) {
+			names = append(names, s)
+		}
+	}
+	return names, nil
+}
+
+// gitOutput runs git command with args and returns its output
+func gitOutput(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, s)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+func isZeroHash(s string) bool { return strings.Trim(s, "0") == "" }
@@ -2,6 +2,13 @@ module github.com/artyom/outline
 
 go 1.24rc1
 
-require rsc.io/markdown v0.0.0-20241212154241-6bf72452917f
+require (
+	github.com/fsnotify/fsnotify v1.10.1
+	gopkg.in/yaml.v3 v3.0.1
+	rsc.io/markdown v0.0.0-20241212154241-6bf72452917f
+)
 
-require golang.org/x/text v0.3.7 // indirect
+require (
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.3.7 // indirect
+)
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"slices"
+
+	"rsc.io/markdown"
+)
+
+func handleGraph(ctx context.Context, token authToken, cliargs []string) error {
+	var dstFile string
+	format := "dot"
+	collection := defaultCollection
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s graph [-collection id] [-format dot|json] [flags]\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nBuilds the graph of links between documents by parsing their text, and prints it\n"+
+			"in Graphviz DOT format, or as JSON with nodes and edges. Links to documents outside of the\n"+
+			"graph are ignored. In JSON, nodes have the number of inbound and outbound links, so pages\n"+
+			"nothing links to stand out.")
+	}
+	fs.StringVar(&collection, "collection", collection, "id or name of the collection to build graph of, if not set, all documents are used")
+	fs.StringVar(&format, "format", format, "output `format`: dot or json")
+	fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+	fs.Parse(cliargs)
+	if format != "dot" && format != "json" {
+		return fmt.Errorf("invalid -format value %q, want dot or json", format)
+	}
+	collection, err := collectionID(ctx, token, collection)
+	if err != nil {
+		return err
+	}
+	g, err := linkGraph(ctx, token, collection)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(dstFile, g)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("digraph outline {\n\tnode [shape=box];\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&buf, "\t%q [label=%q, URL=%q];\n", n.ID, n.Title, webURL(n.URL))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&buf, "\t%q -> %q;\n", e.From, e.To)
+	}
+	buf.WriteString("}\n")
+	return writeResult(dstFile, buf.Bytes())
+}
+
+type graphNode struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	Inbound  int    `json:"inbound"`  // number of documents linking to this one
+	Outbound int    `json:"outbound"` // number of documents this one links to
+}
+
+type graphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type docGraph struct {
+	Nodes []*graphNode `json:"nodes"`
+	Edges []graphEdge  `json:"edges"`
+}
+
+// linkGraph builds the graph of links between documents of the collection,
+// or between all documents if collection is empty. Nodes are sorted by
+// title, edges by their nodes.
+func linkGraph(ctx context.Context, token authToken, collection string) (*docGraph, error) {
+	g := new(docGraph)
+	nodes := make(map[string]*graphNode) // document id and urlId to node
+	var texts []string
+	for d, err := range listDocuments(ctx, token, collection, 0) {
+		if err != nil {
+			return nil, err
+		}
+		n := &graphNode{ID: d.ID, Title: d.Title, URL: d.URL}
+		nodes[d.ID], nodes[d.UrlID] = n, n
+		g.Nodes = append(g.Nodes, n)
+		texts = append(texts, d.Text)
+	}
+	var p markdown.Parser
+	for i, n := range g.Nodes {
+		seen := make(map[string]bool)
+		for link := range docLinks(p.Parse(texts[i])) {
+			id, _, ok := documentLink(link.URL)
+			if !ok {
+				continue
+			}
+			dst, ok := nodes[id]
+			if !ok || dst == n || seen[dst.ID] {
+				continue
+			}
+			seen[dst.ID] = true
+			n.Outbound++
+			dst.Inbound++
+			g.Edges = append(g.Edges, graphEdge{From: n.ID, To: dst.ID})
+		}
+	}
+	slices.SortFunc(g.Nodes, func(a, b *graphNode) int {
+		return cmp.Or(cmp.Compare(a.Title, b.Title), cmp.Compare(a.ID, b.ID))
+	})
+	slices.SortFunc(g.Edges, func(a, b graphEdge) int {
+		return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To))
+	})
+	return g, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+// imageHook describes a command that regenerates local images which paths
+// match the pattern before they are uploaded.
+type imageHook struct {
+	pattern string // path.Match pattern
+	command string // shell command
+}
+
+// imageHooks implements flag.Value, each flag value is in the form of
+// "pattern=command".
+type imageHooks []imageHook
+
+func (h *imageHooks) String() string {
+	var parts []string
+	for _, hook := range *h {
+		parts = append(parts, hook.pattern+"="+hook.command)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (h *imageHooks) Set(s string) error {
+	pattern, command, ok := strings.Cut(s, "=")
+	if !ok || pattern == "" || command == "" {
+		return errors.New("hook must be in the pattern=command form")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid hook pattern %q: %w", pattern, err)
+	}
+	*h = append(*h, imageHook{pattern: pattern, command: command})
+	return nil
+}
+
+// match returns the first hook which pattern matches image path
+func (h imageHooks) match(name string) (imageHook, bool) {
+	for _, hook := range h {
+		if ok, _ := path.Match(hook.pattern, name); ok {
+			return hook, true
+		}
+	}
+	return imageHook{}, false
+}
+
+// uploadImages finds local images referenced by the document, uploads them
+// as attachments to the document docID, and replaces image urls with the
+// attachment urls. Before upload, images matching any of the hooks are
+// regenerated by running the matching hook command. Relative image paths are
+// resolved against the baseDir, which is also the working directory of the
+// commands. Commands are run with the shell and get the image path in the
+// OUTLINE_IMAGE environment variable. If all is false, only images matching
+// hooks are processed.
+//
+// The cache map, if not nil, is used to avoid uploading the same file
+// contents to the document more than once across calls, it is keyed by the
+// document id and contentHash of the image, joined with a colon. New uploads
+// are added to it.
+func uploadImages(ctx context.Context, token authToken, doc *markdown.Document, baseDir, docID string, hooks imageHooks, all bool, cache map[string]string) error {
+	if cache == nil {
+		cache = make(map[string]string)
+	}
+	seen := make(map[string]string) // image path to attachment url
+	for img := range localImages(doc) {
+		if u, ok := seen[img.URL]; ok {
+			img.URL = u
+			continue
+		}
+		p := img.URL
+		if s, err := url.PathUnescape(p); err == nil {
+			p = s
+		}
+		hook, ok := hooks.match(p)
+		if !ok && !all {
+			continue
+		}
+		name := filepath.Join(baseDir, filepath.FromSlash(p))
+		if ok {
+			cmd := shellCommand(ctx, hook.command)
+			cmd.Dir = baseDir
+			cmd.Env = append(os.Environ(), "OUTLINE_IMAGE="+name)
+			cmd.Stdout = os.Stderr
+			cmd.Stderr = os.Stderr
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("refreshing image %s: %w", img.URL, err)
+			}
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		key := docID + ":" + contentHash(data)
+		u, ok := cache[key]
+		if !ok {
+			a, err := uploadAttachment(ctx, token, docID, name, data)
+			if err != nil {
+				return fmt.Errorf("uploading image %s: %w", img.URL, err)
+			}
+			u = a.URL
+			cache[key] = u
+		}
+		seen[img.URL] = u
+		img.URL = u
+	}
+	return nil
+}
+
+// localImages iterates over document images that point to local files
+func localImages(doc *markdown.Document) iter.Seq[*markdown.Image] {
+	return func(yield func(*markdown.Image) bool) {
+		for inl := range docInlines(doc) {
+			if img, ok := inl.(*markdown.Image); ok && isLocalPath(img.URL) && !yield(img) {
+				return
+			}
+		}
+	}
+}
+
+// isLocalPath reports whether link points to a local file rather than to
+// a remote resource or an anchor.
+func isLocalPath(link string) bool {
+	if link == "" || strings.HasPrefix(link, "#") || strings.HasPrefix(link, "/") {
+		return false
+	}
+	if i := strings.IndexAny(link, ":/?#"); i != -1 && link[i] == ':' {
+		return false // has url scheme
+	}
+	return true
+}
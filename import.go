@@ -0,0 +1,322 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+func handleImportDir(ctx context.Context, token authToken, cliargs []string) error {
+	collection := defaultCollection
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s import-dir -collection id [flags] directory\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nDocument foo/bar.md becomes a child of the document foo.md if it exists,\n"+
+			"otherwise an empty document is created for the directory foo.\n"+
+			"Mapping of files to document ids is kept in the %s file in the directory,\n"+
+			"files already mapped are skipped. Once the collection is recorded in that file, the\n"+
+			"-collection flag may be omitted.\n", manifestName)
+	}
+	fs.StringVar(&collection, "collection", collection, "id or name of the collection to create documents in")
+	args := parseInterspersed(fs, cliargs)
+	if len(args) == 0 {
+		return errors.New("want source directory as the first positional argument")
+	}
+	dir := args[0]
+	m, err := readManifest(dir)
+	if err != nil {
+		return err
+	}
+	if m.Collection == "" {
+		if collection == "" {
+			return errors.New("-collection flag must be set")
+		}
+		if m.Collection, err = collectionID(ctx, token, collection); err != nil {
+			return err
+		}
+	}
+	files, err := markdownFiles(dir)
+	if err != nil {
+		return err
+	}
+	var skipped int
+	for _, name := range files {
+		if _, ok := m.Documents[name]; ok {
+			skipped++
+		}
+	}
+	created, err := importFiles(ctx, token, dir, m, files, func(name string, d *document) {
+		fmt.Printf("%s\t%s\t%s\n", name, d.ID, d.Title)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n%d documents created, %d files skipped as already imported\n", created, skipped)
+	return nil
+}
+
+// importFiles creates documents in the m.Collection for the files not yet
+// tracked by the manifest m, as described by the import-dir usage. Files are
+// slash-separated paths relative to the directory dir, as returned by
+// markdownFiles. The manifest is saved after each document is created, and
+// the fn function, if not nil, is called for it. It returns the number of
+// documents created.
+func importFiles(ctx context.Context, token authToken, dir string, m *manifest, files []string, fn func(name string, d *document)) (int, error) {
+	var created int
+	isFile := make(map[string]bool, len(files))
+	for _, name := range files {
+		isFile[name] = true
+	}
+	var ensureDir func(dir string) (string, error)
+	create := func(key, parentDir, title string, doc *markdown.Document, hash string) (string, error) {
+		parent, err := ensureDir(parentDir)
+		if err != nil {
+			return "", err
+		}
+		d, err := createDocument(ctx, token, newDocument{
+			Title:      title,
+			Text:       markdown.Format(doc),
+			Collection: m.Collection,
+			Parent:     parent,
+			Publish:    true,
+		})
+		if err != nil {
+			return "", fmt.Errorf("creating document from %s: %w", key, err)
+		}
+		created++
+		m.Documents[key] = &manifestEntry{ID: d.ID, UpdatedAt: d.UpdatedAt, Hash: hash}
+		if fn != nil {
+			fn(key, d)
+		}
+		return d.ID, m.save(dir)
+	}
+	ensureFile := func(name string) (string, error) {
+		if e, ok := m.Documents[name]; ok {
+			return e.ID, nil
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return "", err
+		}
+		title, doc := parseDocument(data, transformOptions{})
+		if title == "" {
+			title = fileTitle(name)
+		}
+		return create(name, path.Dir(name), title, doc, contentHash(data))
+	}
+	ensureDir = func(name string) (string, error) {
+		if name == "." {
+			return "", nil
+		}
+		if isFile[name+".md"] {
+			return ensureFile(name + ".md")
+		}
+		if e, ok := m.Documents[name]; ok {
+			return e.ID, nil
+		}
+		return create(name, path.Dir(name), path.Base(name), &markdown.Document{}, "")
+	}
+	for _, name := range files {
+		if _, err := ensureFile(name); err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
+// markdownFiles returns slash-separated paths of all *.md files in the
+// directory tree, relative to the directory. Hidden files and directories
+// are skipped.
+func markdownFiles(dir string) ([]string, error) {
+	var out []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".md") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		out = append(out, filepath.ToSlash(rel))
+		return nil
+	})
+	return out, err
+}
+
+// importedPage is a document created from a file of an imported archive,
+// with its text yet to be updated
+type importedPage struct {
+	d   *document
+	doc *markdown.Document
+}
+
+// linkArchiveFiles rewrites local links and images of the page created from
+// the archive file name: links to other imported pages are pointed to their
+// documents, while other archive files are uploaded as attachments to the
+// page document, named by the attachName function. It returns the number of
+// files uploaded.
+func linkArchiveFiles(ctx context.Context, token authToken, files map[string]func() ([]byte, error), pages map[string]*importedPage,
+	name string, attachName func(file string) string) (int, error) {
+	p := pages[name]
+	// target returns archive file name the local link points to, or an
+	// empty string if there is no such file
+	target := func(link string) string {
+		if !isLocalPath(link) {
+			return ""
+		}
+		link, _, _ = strings.Cut(link, "#")
+		if s, err := url.PathUnescape(link); err == nil {
+			link = s
+		}
+		link = path.Join(path.Dir(name), link)
+		if _, ok := files[link]; !ok {
+			return ""
+		}
+		return link
+	}
+	attachments := make(map[string]string) // archive file name to attachment url
+	attach := func(file string) (string, error) {
+		if u, ok := attachments[file]; ok {
+			return u, nil
+		}
+		data, err := files[file]()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", file, err)
+		}
+		a, err := uploadAttachment(ctx, token, p.d.ID, attachName(file), data)
+		if err != nil {
+			return "", fmt.Errorf("uploading %s: %w", file, err)
+		}
+		attachments[file] = a.URL
+		return a.URL, nil
+	}
+	var err error
+	for inl := range docInlines(p.doc) {
+		switch x := inl.(type) {
+		case *markdown.Image:
+			if file := target(x.URL); file != "" {
+				if x.URL, err = attach(file); err != nil {
+					return len(attachments), err
+				}
+			}
+		case *markdown.Link:
+			file := target(x.URL)
+			if file == "" {
+				continue
+			}
+			if dst, ok := pages[file]; ok {
+				_, heading, _ := strings.Cut(x.URL, "#")
+				x.URL = dst.d.URL
+				if heading != "" {
+					x.URL += "#" + heading
+				}
+				continue
+			}
+			if x.URL, err = attach(file); err != nil {
+				return len(attachments), err
+			}
+		}
+	}
+	return len(attachments), nil
+}
+
+// dirFiles returns files of the directory tree by their slash-separated names
+// relative to the directory, as functions reading the file contents. Hidden
+// files and directories are skipped.
+func dirFiles(dir string) (map[string]func() ([]byte, error), error) {
+	out := make(map[string]func() ([]byte, error))
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		out[filepath.ToSlash(rel)] = func() ([]byte, error) { return os.ReadFile(p) }
+		return nil
+	})
+	return out, err
+}
+
+// zipFiles returns files of the zip archive by their slash-separated names, as
+// functions reading the file contents. Archives nested in the top level of
+// the archive are unpacked too, as large exports often come as an archive of
+// several part archives.
+func zipFiles(name string) (map[string]func() ([]byte, error), error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	// archive is kept open until the program exits
+	out := make(map[string]func() ([]byte, error))
+	var add func(r *zip.Reader, nested bool) error
+	add = func(r *zip.Reader, nested bool) error {
+		for _, f := range r.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			if !nested && strings.EqualFold(path.Ext(f.Name), ".zip") {
+				data, err := readZipFile(f)
+				if err != nil {
+					return fmt.Errorf("%s: %w", f.Name, err)
+				}
+				nr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+				if err != nil {
+					return fmt.Errorf("%s: %w", f.Name, err)
+				}
+				if err := add(nr, true); err != nil {
+					return err
+				}
+				continue
+			}
+			out[path.Clean(f.Name)] = func() ([]byte, error) { return readZipFile(f) }
+		}
+		return nil
+	}
+	if err := add(&zr.Reader, false); err != nil {
+		zr.Close()
+		return nil, err
+	}
+	return out, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
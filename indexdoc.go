@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+func handleIndexDoc(ctx context.Context, token authToken, cliargs []string) error {
+	var query, collection, target, title string
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s index-doc (-query text | -collection id) -id url|urlid [flags]\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&query, "query", query, "search query to find documents to link to")
+	fs.StringVar(&collection, "collection", collection, "collection id or name to link all documents of")
+	fs.StringVar(&target, "id", target, "url|urlid of the index document to overwrite")
+	fs.StringVar(&title, "title", title, "index document title, if not set, current title is kept")
+	fs.Parse(cliargs)
+	if (query == "") == (collection == "") {
+		return errors.New("exactly one of -query or -collection flags must be set")
+	}
+	if target == "" {
+		return errors.New("-id flag must be set")
+	}
+	collection, err := collectionID(ctx, token, collection)
+	if err != nil {
+		return err
+	}
+	idx, err := documentInfo(ctx, token, documentID(target))
+	if err != nil {
+		return err
+	}
+	var docs []document
+	if query != "" {
+		results := paginate[searchResult](ctx, token, apiURL("documents.search"), 0, func(p pagination) any {
+			return struct {
+				pagination
+				Query string `json:"query"`
+			}{pagination: p, Query: query}
+		})
+		for r, err := range results {
+			if err != nil {
+				return err
+			}
+			docs = append(docs, r.Document)
+		}
+	} else {
+		for d, err := range listDocuments(ctx, token, collection, 0) {
+			if err != nil {
+				return err
+			}
+			docs = append(docs, d)
+		}
+	}
+	names := make(map[string]string) // collection id to name
+	for c, err := range listCollections(ctx, token) {
+		if err != nil {
+			return err
+		}
+		names[c.ID] = c.Name
+	}
+	byCollection := make(map[string][]document)
+	for _, d := range docs {
+		if d.ID == idx.ID {
+			continue
+		}
+		name := cmp.Or(names[d.CollectionID], "Other")
+		byCollection[name] = append(byCollection[name], d)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "_This document is generated by the `%s index-doc` command, manual changes will be lost._\n", exeName)
+	for _, name := range slices.Sorted(maps.Keys(byCollection)) {
+		docs := byCollection[name]
+		slices.SortFunc(docs, func(a, b document) int {
+			return cmp.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		})
+		fmt.Fprintf(&buf, "\n## %s\n\n", name)
+		for _, d := range docs {
+			fmt.Fprintf(&buf, "- [%s](%s)\n", escapeLinkText(d.Title), d.URL)
+		}
+	}
+	_, err = updateDocument(ctx, token, documentUpdate{Id: idx.ID, Title: title, Text: buf.String()})
+	return err
+}
+
+// escapeLinkText escapes characters that have special meaning inside
+// markdown link text
+func escapeLinkText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`).Replace(s)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"text/tabwriter"
+	"time"
+)
+
+func handleInfo(ctx context.Context, token authToken, cliargs []string) error {
+	var dstFile string
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s info [flags] url|urlid\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url or id")
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	d, err := documentInfo(ctx, token, urlid)
+	if err != nil {
+		return err
+	}
+	shares := []share{}
+	for s, err := range listShares(ctx, token) {
+		if err != nil {
+			return err
+		}
+		if s.DocumentID == d.ID {
+			shares = append(shares, s)
+		}
+	}
+	if jsonOutput {
+		info := struct {
+			*document
+			URL    string  `json:"url"`
+			Text   string  `json:"text,omitempty"`
+			Shares []share `json:"shares"`
+		}{document: d, URL: webURL(d.URL), Shares: shares}
+		return writeJSON(dstFile, info)
+	}
+	orNone := func(t *time.Time, none string) string {
+		if t == nil {
+			return none
+		}
+		return t.Format(time.RFC3339)
+	}
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "id:\t%s\n", d.ID)
+	fmt.Fprintf(tw, "title:\t%s\n", d.Title)
+	fmt.Fprintf(tw, "url:\t%s\n", webURL(d.URL))
+	fmt.Fprintf(tw, "collection:\t%s\n", cmp.Or(d.CollectionID, "-"))
+	fmt.Fprintf(tw, "parent:\t%s\n", cmp.Or(d.ParentID, "-"))
+	fmt.Fprintf(tw, "created:\t%s by %s\n", d.CreatedAt.Format(time.RFC3339), d.CreatedBy.Name)
+	fmt.Fprintf(tw, "updated:\t%s by %s\n", d.UpdatedAt.Format(time.RFC3339), d.UpdatedBy.Name)
+	fmt.Fprintf(tw, "published:\t%s\n", orNone(d.PublishedAt, "no, draft"))
+	fmt.Fprintf(tw, "archived:\t%s\n", orNone(d.ArchivedAt, "no"))
+	fmt.Fprintf(tw, "revision:\t%d\n", d.Revision)
+	if d.Template {
+		fmt.Fprintln(tw, "template:\tyes")
+	}
+	if len(shares) == 0 {
+		fmt.Fprintln(tw, "shares:\tnone")
+	}
+	for _, s := range shares {
+		access := "private"
+		if s.Published {
+			access = "public"
+		}
+		fmt.Fprintf(tw, "shares:\t%s (%s)\n", s.URL, access)
+	}
+	tw.Flush()
+	return writeResult(dstFile, buf.Bytes())
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// keychainService is the service name tokens are saved under in the system
+// keychain, the account name is the Outline instance url
+const keychainService = "outline"
+
+// errNoKeychainToken is returned by keychainGet when no token is saved
+var errNoKeychainToken = errors.New("no token saved in the system keychain")
+
+func handleLogin(ctx context.Context, _ authToken, cliargs []string) error {
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s login\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nReads API token from stdin, checks it, and saves it to the system keychain"+
+			"\n(macOS Keychain, Secret Service via secret-tool, or Windows Credential Manager)."+
+			"\nSaved token is used when no other token source is configured.")
+	}
+	fs.Parse(cliargs)
+	if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintf(os.Stderr, "API token for %s: ", baseURL)
+	}
+	sc := bufio.NewScanner(os.Stdin)
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return err
+		}
+		return errors.New("no token received")
+	}
+	token := authToken(strings.TrimSpace(sc.Text()))
+	if token == "" {
+		return errors.New("empty token")
+	}
+	info, err := fetchAuthInfo(ctx, token)
+	if err != nil {
+		return fmt.Errorf("checking token: %w", err)
+	}
+	if err := keychainSet(baseURL, string(token)); err != nil {
+		return fmt.Errorf("saving token: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Logged in to %s as %s <%s>\n", info.Team.Name, info.User.Name, info.User.Email)
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+func keychainGet(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", errNoKeychainToken
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func keychainSet(account, secret string) error {
+	// -w without a value as the last argument makes security prompt for the
+	// password twice, it is written to stdin to keep it off the command line
+	cmd := exec.Command("security", "add-generic-password", "-U", "-s", keychainService, "-a", account, "-w")
+	cmd.Stdin = strings.NewReader(secret + "\n" + secret + "\n")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.New(strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+//go:build !darwin && !windows
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// Secret Service is accessed with the secret-tool program shipped with
+// libsecret
+
+func keychainGet(account string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", keychainService, "account", account).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) || errors.Is(err, exec.ErrNotFound) {
+			return "", errNoKeychainToken
+		}
+		return "", err
+	}
+	if s := strings.TrimSpace(string(out)); s != "" {
+		return s, nil
+	}
+	return "", errNoKeychainToken
+}
+
+func keychainSet(account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label=Outline API token ("+account+")",
+		"service", keychainService, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if s := strings.TrimSpace(string(out)); s != "" {
+			return errors.New(s)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Limits applied when reading API responses, so that a misbehaving server or
+// proxy can't make the process consume unbounded memory. They can be
+// overridden with the OUTLINE_MAX_RESPONSE_SIZE (bytes) and
+// OUTLINE_MAX_JSON_DEPTH environment variables.
+var (
+	maxResponseSize int64 = 64 << 20
+	maxJSONDepth          = 256
+)
+
+func loadLimits() error {
+	if s := os.Getenv("OUTLINE_MAX_RESPONSE_SIZE"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid OUTLINE_MAX_RESPONSE_SIZE value %q", s)
+		}
+		maxResponseSize = n
+	}
+	if s := os.Getenv("OUTLINE_MAX_JSON_DEPTH"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid OUTLINE_MAX_JSON_DEPTH value %q", s)
+		}
+		maxJSONDepth = n
+	}
+	return nil
+}
+
+// readLimited reads r until EOF, failing if it holds more than limit bytes
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("response body exceeds %d bytes limit", limit)
+	}
+	return data, nil
+}
+
+// checkJSONDepth returns an error if JSON-encoded data has objects or arrays
+// nested deeper than maxDepth levels. It does not validate JSON otherwise.
+func checkJSONDepth(data []byte, maxDepth int) error {
+	var depth int
+	var inString, escaped bool
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			if depth++; depth > maxDepth {
+				return fmt.Errorf("response JSON nesting exceeds %d levels limit", maxDepth)
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestCheckJSONDepth(t *testing.T) {
+	for _, tc := range []struct {
+		data string
+		ok   bool
+	}{
+		{`{}`, true},
+		{`{"a":[1,2,{"b":3}]}`, true},
+		{`[[[[]]]]`, false},
+		{`[[[]]]`, true},
+		{`{"a":"[[[[[[["}`, true},   // brackets inside strings do not count
+		{`{"a":"\"[[[[[[["}`, true}, // escaped quote does not end the string
+		{`{"a":"\\"}`, true},        // escaped backslash before the closing quote
+		{`[{"a":[{"b":[]}]}]`, false},
+		{``, true},
+	} {
+		err := checkJSONDepth([]byte(tc.data), 3)
+		if (err == nil) != tc.ok {
+			t.Errorf("checkJSONDepth(%s, 3) = %v, want ok=%t", tc.data, err, tc.ok)
+		}
+	}
+}
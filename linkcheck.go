@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"rsc.io/markdown"
+)
+
+func handleLinkCheck(ctx context.Context, token authToken, cliargs []string) error {
+	var external bool
+	collection := defaultCollection
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s linkcheck [-collection id] [flags]\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nChecks that links to documents point to existing documents, and that links to headings\n"+
+			"point to headings present in the target documents. Broken links are reported per document,\n"+
+			"and the command exits with non-zero status if there are any.")
+	}
+	fs.StringVar(&collection, "collection", collection, "id or name of the collection to check documents of, if not set, all documents are checked")
+	fs.BoolVar(&external, "external", external, "also check that external http(s) links respond without errors")
+	fs.Parse(cliargs)
+	collection, err := collectionID(ctx, token, collection)
+	if err != nil {
+		return err
+	}
+	var docs []document
+	targets := make(map[string]*document) // document id and urlId to document
+	for d, err := range listDocuments(ctx, token, collection, 0) {
+		if err != nil {
+			return err
+		}
+		docs = append(docs, d)
+	}
+	for i := range docs {
+		targets[docs[i].ID], targets[docs[i].UrlID] = &docs[i], &docs[i]
+	}
+	slugs := make(map[string][]string) // document id to its heading slugs
+	headings := func(d *document) []string {
+		s, ok := slugs[d.ID]
+		if !ok {
+			var p markdown.Parser
+			s = headingSlugs(p.Parse(d.Text), slugOutline)
+			slugs[d.ID] = s
+		}
+		return s
+	}
+	missing := make(map[string]string) // document id or urlId to error for documents that cannot be fetched
+	checked := make(map[string]error)  // external url to check result
+	client := &http.Client{Timeout: 20 * time.Second}
+	var broken int
+	for i := range docs {
+		d := &docs[i]
+		var problems []string
+		var p markdown.Parser
+		for link := range docLinks(p.Parse(d.Text)) {
+			if id, heading, ok := documentLink(link.URL); ok {
+				target, ok := targets[id]
+				if !ok {
+					if reason, ok := missing[id]; ok {
+						problems = append(problems, fmt.Sprintf("%s: %s", link.URL, reason))
+						continue
+					}
+					t, err := documentInfo(ctx, token, id)
+					// Outline responds with 403 to requests for documents
+					// the user has no access to
+					var apiErr *apiError
+					if errors.Is(err, ErrNotFound) || errors.As(err, &apiErr) && apiErr.Status == http.StatusForbidden {
+						missing[id] = "no such document, or it is not accessible"
+						problems = append(problems, fmt.Sprintf("%s: %s", link.URL, missing[id]))
+						continue
+					}
+					if err != nil {
+						return err
+					}
+					target = t
+					targets[t.ID], targets[t.UrlID] = t, t
+				}
+				if heading != "" && !slices.Contains(headings(target), heading) {
+					problems = append(problems, fmt.Sprintf("%s: document %q has no such heading", link.URL, target.Title))
+				}
+				continue
+			}
+			if heading, ok := strings.CutPrefix(link.URL, "#"); ok {
+				if heading != "" && !slices.Contains(headings(d), heading) {
+					problems = append(problems, fmt.Sprintf("%s: no such heading", link.URL))
+				}
+				continue
+			}
+			if !external || !(strings.HasPrefix(link.URL, "http://") || strings.HasPrefix(link.URL, "https://")) ||
+				strings.HasPrefix(link.URL, webURL("/")) {
+				continue
+			}
+			err, ok := checked[link.URL]
+			if !ok {
+				err = checkExternalLink(ctx, client, link.URL)
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				checked[link.URL] = err
+			}
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", link.URL, err))
+			}
+		}
+		if len(problems) == 0 {
+			continue
+		}
+		broken += len(problems)
+		fmt.Printf("%s (%s):\n", d.Title, webURL(d.URL))
+		for _, s := range problems {
+			fmt.Printf("\t%s\n", s)
+		}
+	}
+	if broken != 0 {
+		return fmt.Errorf("%d broken link(s) found", broken)
+	}
+	return nil
+}
+
+// checkExternalLink checks that the url responds with a non-error status.
+// Servers not supporting HEAD requests are asked with GET.
+func checkExternalLink(ctx context.Context, client *http.Client, link string) error {
+	var status int
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, link, nil)
+		if err != nil {
+			return err
+		}
+		// extra headers meant for the Outline instance are not sent
+		req.Header.Set("User-Agent", userAgent())
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		status = resp.StatusCode
+		if status < 400 {
+			return nil
+		}
+		if status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented && status != http.StatusForbidden {
+			break
+		}
+	}
+	return fmt.Errorf("unexpected status: %d %s", status, http.StatusText(status))
+}
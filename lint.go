@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"rsc.io/markdown"
+)
+
+func handleLint(ctx context.Context, token authToken, cliargs []string) error {
+	var schemaFile, collection string
+	var a11y bool
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s lint [-schema schema.yaml] [-a11y] [flags] [document.md...]\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), lintSchemaHelp)
+	}
+	fs.StringVar(&schemaFile, "schema", schemaFile, "`file` with the YAML schema documents are checked against")
+	fs.StringVar(&collection, "collection", collection, "collection id or name to check all documents of")
+	fs.BoolVar(&a11y, "a11y", a11y, "check images for missing or placeholder alt text and links for non-descriptive text")
+	fs.Parse(cliargs)
+	if schemaFile == "" && !a11y {
+		return errors.New("either -schema or -a11y flag must be set")
+	}
+	if collection == "" && fs.NArg() == 0 {
+		return errors.New("either -collection flag or local documents must be given")
+	}
+	collection, err := collectionID(ctx, token, collection)
+	if err != nil {
+		return err
+	}
+	var checks []func(*markdown.Document) []string
+	if schemaFile != "" {
+		schema, err := readLintSchema(schemaFile)
+		if err != nil {
+			return err
+		}
+		checks = append(checks, schema.check)
+	}
+	if a11y {
+		checks = append(checks, checkAccessibility)
+	}
+	check := func(doc *markdown.Document) []string {
+		var out []string
+		for _, fn := range checks {
+			out = append(out, fn(doc)...)
+		}
+		return out
+	}
+	var failed int
+	report := func(name string, violations []string) {
+		if len(violations) == 0 {
+			return
+		}
+		failed++
+		fmt.Printf("%s:\n", name)
+		for _, s := range violations {
+			fmt.Printf("\t%s\n", s)
+		}
+	}
+	for _, name := range fs.Args() {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		var p markdown.Parser
+		report(name, check(p.Parse(string(data))))
+	}
+	if collection != "" {
+		for d, err := range listDocuments(ctx, token, collection, 0) {
+			if err != nil {
+				return err
+			}
+			var p markdown.Parser
+			report(fmt.Sprintf("%s (%s)", d.Title, d.UrlID), check(p.Parse(d.Text)))
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d document(s) failed checks", failed)
+	}
+	return nil
+}
+
+const lintSchemaHelp = `
+Schema file example:
+
+  # headings every document must have, matched case-insensitively
+  requiredSections:
+    - Rollback
+    - Contacts
+`
+
+type lintSchema struct {
+	RequiredSections []string `yaml:"requiredSections"`
+}
+
+func readLintSchema(name string) (*lintSchema, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var s lintSchema
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("reading schema %s: %w", name, err)
+	}
+	return &s, nil
+}
+
+// check returns a list of human-readable schema violations found in doc
+func (s *lintSchema) check(doc *markdown.Document) []string {
+	var out []string
+	headings := make(map[string]struct{})
+	for _, b := range doc.Blocks {
+		if h, ok := b.(*markdown.Heading); ok {
+			headings[strings.ToLower(strings.TrimSpace(inlinesText(h.Text.Inline)))] = struct{}{}
+		}
+	}
+	for _, name := range s.RequiredSections {
+		if _, ok := headings[strings.ToLower(strings.TrimSpace(name))]; !ok {
+			out = append(out, fmt.Sprintf("missing required section %q", name))
+		}
+	}
+	return out
+}
+
+// checkAccessibility reports images without meaningful alt text and links
+// whose text says nothing about the link target.
+func checkAccessibility(doc *markdown.Document) []string {
+	var out []string
+	for inl := range docInlines(doc) {
+		switch x := inl.(type) {
+		case *markdown.Image:
+			alt := strings.TrimSpace(inlinesText(x.Inner))
+			switch {
+			case alt == "":
+				out = append(out, fmt.Sprintf("image %s: missing alt text", x.URL))
+			case isPlaceholderAlt(alt, x.URL):
+				out = append(out, fmt.Sprintf("image %s: placeholder alt text %q", x.URL, alt))
+			}
+		case *markdown.Link:
+			text := strings.TrimSpace(inlinesText(x.Inner))
+			switch strings.ToLower(strings.TrimRight(text, ".!")) {
+			case "here", "click here", "link", "this link", "this", "more", "read more":
+				out = append(out, fmt.Sprintf("link %s: non-descriptive text %q", x.URL, text))
+			}
+		}
+	}
+	return out
+}
+
+// isPlaceholderAlt reports whether image alt text is a generic placeholder,
+// like the one editors insert by default, or the image file name.
+func isPlaceholderAlt(alt, url string) bool {
+	switch strings.ToLower(alt) {
+	case "image", "img", "picture", "pic", "photo", "screenshot", "alt", "alt text", "untitled":
+		return true
+	}
+	if i := strings.LastIndexByte(url, '/'); i != -1 {
+		url = url[i+1:]
+	}
+	return strings.EqualFold(alt, url) || strings.EqualFold(alt, strings.TrimSuffix(url, path.Ext(url)))
+}
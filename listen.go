@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func handleListen(ctx context.Context, _ authToken, cliargs []string) error {
+	addr := "localhost:8080"
+	var command string
+	secret := os.Getenv("OUTLINE_WEBHOOK_SECRET")
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s listen [flags]\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nReceives Outline webhook requests, see webhooks subcommand. Each event is either printed to stdout\n"+
+			"as a line of JSON, or passed to the -exec command on stdin, with the event name in the OUTLINE_EVENT\n"+
+			"environment variable. Events are processed one at a time, in the order they are received;\n"+
+			"if the command fails, request is answered with an error, so Outline retries it later.")
+	}
+	fs.StringVar(&addr, "addr", addr, "address to listen on")
+	fs.StringVar(&command, "exec", command, "shell `command` to run for each event")
+	fs.StringVar(&secret, "secret", secret, "webhook signing secret, requests without a valid signature are rejected;\n"+
+		"defaults to OUTLINE_WEBHOOK_SECRET environment variable")
+	fs.Parse(cliargs)
+	if secret == "" {
+		log.Print("warning: no webhook secret is set, requests are not verified")
+	}
+	var mu sync.Mutex
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 10<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if secret != "" && !validWebhookSignature(r.Header.Get("Outline-Signature"), body, secret, time.Now()) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		var ev struct {
+			Event string `json:"event"`
+		}
+		if err := json.Unmarshal(body, &ev); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		// command is not tied to the request, so it is not killed if Outline
+		// gives up waiting for the response
+		if err := handleWebhookEvent(ctx, command, ev.Event, body); err != nil {
+			log.Printf("%s: %v", ev.Event, err)
+			http.Error(w, "event processing failed", http.StatusInternalServerError)
+			return
+		}
+	}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           http.HandlerFunc(handler),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("listening on %s", addr)
+	return srv.ListenAndServe()
+}
+
+// handleWebhookEvent runs command with the event on stdin, or prints the
+// event as a single line of JSON to stdout if command is empty
+func handleWebhookEvent(ctx context.Context, command, event string, body []byte) error {
+	if command == "" {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, body); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	cmd := shellCommand(ctx, command)
+	cmd.Env = append(os.Environ(), "OUTLINE_EVENT="+event)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// webhookMaxSkew is how far from now the timestamp of a signed webhook
+// request may be, so captured requests cannot be replayed later
+const webhookMaxSkew = 5 * time.Minute
+
+// validWebhookSignature checks the Outline-Signature header value in the
+// "t=timestamp,s=signature" form, where signature is hex-encoded HMAC-SHA256
+// of the timestamp and the request body joined with a dot. Timestamp, in
+// milliseconds since the epoch, must be within webhookMaxSkew of now.
+func validWebhookSignature(header string, body []byte, secret string, now time.Time) bool {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "s":
+			sig = v
+		}
+	}
+	got, err := hex.DecodeString(sig)
+	if ts == "" || err != nil {
+		return false
+	}
+	ms, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return false
+	}
+	if d := now.Sub(time.UnixMilli(ms)); d > webhookMaxSkew || d < -webhookMaxSkew {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestValidWebhookSignature(t *testing.T) {
+	const secret = "s3cret"
+	body := []byte(`{"event":"documents.update"}`)
+	now := time.UnixMilli(1700000000000)
+	sign := func(ts time.Time, body []byte, secret string) string {
+		t := strconv.FormatInt(ts.UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(t + "."))
+		mac.Write(body)
+		return "t=" + t + ",s=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	for _, tc := range []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"valid", sign(now, body, secret), true},
+		{"with spaces", "t=" + strconv.FormatInt(now.UnixMilli(), 10) + ", s=" + sign(now, body, secret)[len("t=1700000000000,s="):], true},
+		{"slightly old", sign(now.Add(-time.Minute), body, secret), true},
+		{"slightly ahead", sign(now.Add(time.Minute), body, secret), true},
+		{"replayed", sign(now.Add(-time.Hour), body, secret), false},
+		{"far future", sign(now.Add(time.Hour), body, secret), false},
+		{"wrong secret", sign(now, body, "other"), false},
+		{"other body", sign(now, []byte(`{}`), secret), false},
+		{"no timestamp", "s=" + sign(now, body, secret)[len("t=1700000000000,s="):], false},
+		{"bad hex", "t=1700000000000,s=zz", false},
+		{"empty", "", false},
+	} {
+		if got := validWebhookSignature(tc.header, body, secret, now); got != tc.want {
+			t.Errorf("%s: validWebhookSignature(%q) = %t, want %t", tc.name, tc.header, got, tc.want)
+		}
+	}
+}
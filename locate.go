@@ -0,0 +1,171 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// docLocator holds flags that let subcommands taking a document url|urlid
+// argument select the document in other ways
+type docLocator struct {
+	path  string
+	title string
+	// titleFlag is the name of the flag to select document by its title,
+	// "title" if empty; subcommands already using -title flag for other
+	// purposes set it to something else
+	titleFlag string
+}
+
+func (l *docLocator) register(fs *flag.FlagSet) {
+	l.titleFlag = cmp.Or(l.titleFlag, "title")
+	fs.StringVar(&l.path, "path", l.path, "select document by its `path` of collection name and document titles,\n"+
+		"like \"Engineering/Onboarding/Laptop setup\", instead of url|urlid")
+	fs.StringVar(&l.title, l.titleFlag, l.title, "select document by its exact `title` instead of url|urlid")
+}
+
+// set reports whether the document is selected by the locator flags
+func (l *docLocator) set() bool { return l.path != "" || l.title != "" }
+
+// flags returns names of the locator flags for use in error messages
+func (l *docLocator) flags() string { return "-path or -" + l.titleFlag }
+
+// docArgs returns the number of positional arguments naming the document:
+// none if the document is selected by the locator flags, one otherwise
+func (l *docLocator) docArgs() int {
+	if l.set() {
+		return 0
+	}
+	return 1
+}
+
+// resolve returns the id of the document selected by the locator flags, or, if
+// none are set, the one taken from the first of args. The remaining args are
+// returned as is.
+func (l *docLocator) resolve(ctx context.Context, token authToken, args []string) (string, []string, error) {
+	switch {
+	case l.path != "" && l.title != "":
+		return "", nil, fmt.Errorf("-path and -%s flags are mutually exclusive", l.titleFlag)
+	case l.path != "":
+		id, err := resolvePath(ctx, token, l.path)
+		return id, args, err
+	case l.title != "":
+		id, err := resolveTitle(ctx, token, l.title)
+		return id, args, err
+	}
+	if len(args) == 0 {
+		return "", nil, errors.New("want document url/urlid as the first positional argument")
+	}
+	return documentID(args[0]), args[1:], nil
+}
+
+// resolvePath finds the document by its path of collection name and titles
+// of the document and its parents, separated by slashes. Names are matched
+// case-insensitively; names with slashes are matched as is.
+func resolvePath(ctx context.Context, token authToken, p string) (string, error) {
+	p = strings.Trim(p, "/")
+	var found []pathMatch
+	for c, err := range listCollections(ctx, token) {
+		if err != nil {
+			return "", err
+		}
+		rest, ok := cutName(p, c.Name)
+		if !ok || rest == "" {
+			continue
+		}
+		nodes, err := collectionTree(ctx, token, c.ID)
+		if err != nil {
+			return "", err
+		}
+		found = findPath(found, nodes, rest, c.Name)
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no document found at path %q", p)
+	case 1:
+		return found[0].id, nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "path %q is ambiguous, matching documents:", p)
+	for _, m := range found {
+		fmt.Fprintf(&b, "\n\t%s\t%s", m.id, m.path)
+	}
+	return "", errors.New(b.String())
+}
+
+// resolveTitle finds the document by its exact title, matched
+// case-insensitively
+func resolveTitle(ctx context.Context, token authToken, title string) (string, error) {
+	d, err := findTitle(ctx, token, title)
+	if err != nil {
+		return "", err
+	}
+	return d.ID, nil
+}
+
+// findTitle is like resolveTitle, but returns the document found
+func findTitle(ctx context.Context, token authToken, title string) (*document, error) {
+	results, err := searchDocuments(ctx, token, searchQuery{Query: title, Limit: 100})
+	if err != nil {
+		return nil, err
+	}
+	var found []document
+	for _, r := range results {
+		if strings.EqualFold(strings.TrimSpace(r.Document.Title), strings.TrimSpace(title)) {
+			found = append(found, r.Document)
+		}
+	}
+	if len(found) == 1 {
+		return &found[0], nil
+	}
+	var b strings.Builder
+	if len(found) == 0 {
+		fmt.Fprintf(&b, "no document titled %q", title)
+		if len(results) == 0 {
+			return nil, errors.New(b.String())
+		}
+		b.WriteString(", similar documents:")
+		for _, r := range results[:min(len(results), 5)] {
+			found = append(found, r.Document)
+		}
+	} else {
+		fmt.Fprintf(&b, "title %q is ambiguous, matching documents:", title)
+	}
+	for _, d := range found {
+		fmt.Fprintf(&b, "\n\t%s\t%s\t%s", d.UrlID, d.Title, webURL(d.URL))
+	}
+	return nil, errors.New(b.String())
+}
+
+type pathMatch struct{ id, path string }
+
+// findPath appends to dst documents from the tree nodes matching path p,
+// prefix is the path of the nodes' parent
+func findPath(dst []pathMatch, nodes []navNode, p, prefix string) []pathMatch {
+	for _, n := range nodes {
+		rest, ok := cutName(p, n.Title)
+		switch {
+		case !ok:
+		case rest == "":
+			dst = append(dst, pathMatch{id: n.ID, path: prefix + "/" + n.Title})
+		default:
+			dst = findPath(dst, n.Children, rest, prefix+"/"+n.Title)
+		}
+	}
+	return dst
+}
+
+// cutName reports whether path p starts with the element name, and returns
+// the rest of the path after it
+func cutName(p, name string) (rest string, ok bool) {
+	if len(p) < len(name) || !strings.EqualFold(p[:len(name)], name) {
+		return "", false
+	}
+	if rest = p[len(name):]; rest == "" {
+		return "", true
+	}
+	return strings.CutPrefix(rest, "/")
+}
@@ -1,19 +1,26 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"iter"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
-	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 
 	"rsc.io/markdown"
@@ -24,30 +31,148 @@ var exeName = filepath.Base(os.Args[0])
 func main() {
 	log.SetFlags(0)
 	commands := []subcommand{
-		{name: "get", fn: handleGet, desc: "download a single document"},
+		{name: "whoami", fn: handleWhoami, desc: "show the user and team the token belongs to"},
+		{name: "get", fn: handleGet, desc: "download documents"},
 		{name: "update", fn: handleUpdate, desc: "replace document with a content from file"},
+		{name: "create", fn: handleCreate, desc: "create new document from file"},
+		{name: "templatize", fn: handleTemplatize, desc: "create a template from the document"},
+		{name: "duplicate", fn: handleDuplicate, desc: "create a copy of the document"},
+		{name: "diff", fn: handleDiff, desc: "compare local file with the document"},
+		{name: "check", fn: handleCheck, desc: "verify that mapped local files match their documents"},
+		{name: "hook", fn: handleHook, desc: "update documents from files changed in pushed commits, for use as git hook"},
 		{name: "search", fn: handleSearch, desc: "search for documents"},
+		{name: "list", fn: handleList, desc: "list documents"},
+		{name: "drafts", fn: handleDrafts, desc: "list your unpublished drafts"},
+		{name: "lint", fn: handleLint, desc: "check documents against a schema"},
+		{name: "publish", fn: handlePublish, desc: "publish draft document"},
+		{name: "unpublish", fn: handleUnpublish, desc: "turn published document back into a draft"},
+		{name: "delete", fn: handleDelete, desc: "move document to trash or delete it permanently"},
+		{name: "archive", fn: handleArchive, desc: "archive document"},
+		{name: "unarchive", fn: handleUnarchive, desc: "restore archived document"},
+		{name: "trash", fn: handleTrash, desc: "list, restore, or permanently delete documents in trash"},
+		{name: "revisions", fn: handleRevisions, desc: "list document revisions"},
+		{name: "revdiff", fn: handleRevDiff, desc: "compare document revisions"},
+		{name: "restore", fn: handleRestore, desc: "restore document to a previous revision"},
+		{name: "comments", fn: handleComments, desc: "list document comments"},
+		{name: "comment", fn: handleComment, desc: "add a comment to the document"},
+		{name: "open", fn: handleOpen, desc: "open document in the web browser"},
+		{name: "info", fn: handleInfo, desc: "show document metadata"},
+		{name: "star", fn: handleStar, desc: "star document"},
+		{name: "unstar", fn: handleUnstar, desc: "remove document star"},
+		{name: "starred", fn: handleStarred, desc: "list starred documents"},
+		{name: "pin", fn: handlePin, desc: "pin document to the home page or collection"},
+		{name: "unpin", fn: handleUnpin, desc: "unpin document"},
+		{name: "subscribe", fn: handleSubscribe, desc: "subscribe to document updates"},
+		{name: "unsubscribe", fn: handleUnsubscribe, desc: "unsubscribe from document updates"},
+		{name: "backlinks", fn: handleBacklinks, desc: "list documents linking to the document"},
+		{name: "graph", fn: handleGraph, desc: "export graph of links between documents"},
+		{name: "linkcheck", fn: handleLinkCheck, desc: "find broken links to documents, headings, and external pages"},
+		{name: "merge", fn: handleMerge, desc: "merge several documents into one"},
+		{name: "split", fn: handleSplit, desc: "split document into child documents by its sections"},
+		{name: "move", fn: handleMove, desc: "move document to another collection or parent"},
+		{name: "enforce-policy", fn: handleEnforcePolicy, desc: "archive or flag stale documents according to a policy file"},
+		{name: "export", fn: handleExport, desc: "export collection into an archive"},
+		{name: "backup", fn: handleBackup, desc: "export all workspace collections into an archive"},
+		{name: "export-collection", fn: handleExportCollection, desc: "save all collection documents into a directory tree"},
+		{name: "site", fn: handleSite, desc: "render collection as a static HTML site"},
+		{name: "import-dir", fn: handleImportDir, desc: "create documents from a directory tree of markdown files"},
+		{name: "import-notion", fn: handleImportNotion, desc: "create documents from a Notion markdown export"},
+		{name: "import-confluence", fn: handleImportConfluence, desc: "create documents from a Confluence space HTML export"},
+		{name: "import-obsidian", fn: handleImportObsidian, desc: "create documents from an Obsidian vault, converting wikilinks"},
+		{name: "sync", fn: handleSync, desc: "synchronize a directory of markdown files with documents"},
+		{name: "stdio-server", fn: handleStdioServer, desc: "serve JSON-RPC requests over stdin/stdout for editor integrations"},
+		{name: "index-doc", fn: handleIndexDoc, desc: "generate an index document linking to search results or collection documents"},
+		{name: "review-next", fn: handleReviewNext, desc: "pick the least recently updated document for review"},
+		{name: "users", fn: handleUsers, desc: "list, invite, suspend, or activate users"},
+		{name: "groups", fn: handleGroups, desc: "list or create groups, manage group members"},
+		{name: "events", fn: handleEvents, desc: "export audit log events as NDJSON"},
+		{name: "webhooks", fn: handleWebhooks, desc: "list, create, or delete webhook subscriptions"},
+		{name: "collections", fn: handleCollections, desc: "list, create, update, or delete collections"},
+		{name: "attachments", fn: handleAttachments, desc: "report attachments storage usage"},
+		{name: "attach", fn: handleAttach, desc: "upload or download attachments"},
+		{name: "share", fn: handleShare, desc: "create, list, or revoke document share links"},
 	}
+	commands = append(commands,
+		subcommand{name: "convert", fn: handleConvert, desc: "transform local markdown the way update or get does it, offline", noAuth: true},
+		subcommand{name: "serve", fn: handleServe, desc: "preview local document in the browser, reloading it on changes", noAuth: true},
+		subcommand{name: "listen", fn: handleListen, desc: "receive webhook events and run a command for each", noAuth: true},
+		subcommand{name: "login", fn: handleLogin, desc: "save API token to the system keychain", noAuth: true, needsProfile: true},
+		subcommand{name: "version", fn: handleVersion, desc: "print version and build information", noAuth: true},
+		subcommand{name: "help", desc: "show help on subcommand", noAuth: true,
+			fn: func(ctx context.Context, _ authToken, args []string) error {
+				if len(args) == 0 {
+					printUsage(os.Stdout, commands)
+					return nil
+				}
+				for _, cmd := range commands {
+					if cmd.name == args[0] && cmd.name != "help" {
+						return cmd.fn(ctx, "", []string{"-h"})
+					}
+				}
+				return fmt.Errorf("unknown subcommand %q", args[0])
+			}},
+	)
 	usage := func() {
-		w := flag.CommandLine.Output()
-		fmt.Fprintf(w, "Usage: %s [subcommand] [flags]\n", exeName)
-		for _, c := range commands {
-			fmt.Fprintf(w, "\t%-15s %s\n", c.name, c.desc)
-		}
+		printUsage(flag.CommandLine.Output(), commands)
 		os.Exit(2)
 	}
-	if len(os.Args) < 2 {
+	flag.Usage = usage
+	var rateLimit, profileName string
+	flag.BoolVar(&jsonOutput, "json", jsonOutput, "")
+	flag.StringVar(&rateLimit, "rate-limit", rateLimit, "")
+	flag.StringVar(&profileName, "profile", profileName, "")
+	flag.BoolVar(&debugHTTP, "debug", debugHTTP, "")
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
 		usage()
 	}
 	for _, cmd := range commands {
-		if os.Args[1] != cmd.name {
+		if args[0] != cmd.name {
 			continue
 		}
-		token := authToken(os.Getenv("OUTLINE_TOKEN"))
-		if token == "" {
-			log.Fatal("OUTLINE_TOKEN is not set")
+		if err := loadLimits(); err != nil {
+			log.Fatal(err)
+		}
+		if s := cmp.Or(rateLimit, os.Getenv("OUTLINE_RATE_LIMIT")); s != "" {
+			var err error
+			if rateLimiter, err = parseRate(s); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if s := os.Getenv("OUTLINE_DEBUG"); s != "" && !debugHTTP {
+			var err error
+			if debugHTTP, err = strconv.ParseBool(s); err != nil {
+				log.Fatalf("invalid OUTLINE_DEBUG value %q", s)
+			}
+		}
+		if s := os.Getenv("OUTLINE_HEADERS"); s != "" {
+			var err error
+			if extraHeaders, err = parseHeaders(s); err != nil {
+				log.Fatalf("OUTLINE_HEADERS: %v", err)
+			}
+		}
+		// commands working offline run even if the config file is broken
+		p := &profile{}
+		if !cmd.noAuth || cmd.needsProfile {
+			var err error
+			if p, err = loadProfile(cmp.Or(profileName, os.Getenv("OUTLINE_PROFILE"))); err != nil {
+				log.Fatal(err)
+			}
 		}
-		if err := cmd.fn(context.Background(), token, os.Args[2:]); err != nil {
+		baseURL = cmp.Or(p.BaseURL, strings.TrimSuffix(os.Getenv("OUTLINE_BASE_URL"), "/"), baseURL)
+		defaultCollection = p.Collection
+		var token authToken
+		if !cmd.noAuth {
+			var err error
+			if token, err = p.authToken(context.Background()); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err := cmd.fn(context.Background(), token, args[1:]); err != nil {
+			if errors.Is(err, ErrUnauthorized) {
+				log.Fatalf("%v\nthe API token for %s is invalid or expired, see %s login -h", err, baseURL, exeName)
+			}
 			log.Fatal(err)
 		}
 		return
@@ -55,92 +180,752 @@ func main() {
 	usage()
 }
 
+func printUsage(w io.Writer, commands []subcommand) {
+	fmt.Fprintf(w, "Usage: %s [-json] [-rate-limit N/s] [-profile name] [-debug] [subcommand] [flags]\n", exeName)
+	for _, c := range commands {
+		fmt.Fprintf(w, "\t%-18s %s\n", c.name, c.desc)
+	}
+	fmt.Fprintln(w, "\nGlobal -json flag makes subcommands that list or fetch documents, collections, and other objects print JSON.\n"+
+		"Global -rate-limit flag (or OUTLINE_RATE_LIMIT environment variable) limits the rate of API requests,\n"+
+		"given as a number of requests per second (N or N/s), minute (N/m), or hour (N/h).\n"+
+		"Global -debug flag (or OUTLINE_DEBUG=1 environment variable) dumps HTTP requests and responses,\n"+
+		"including bodies, to stderr; the API token and credential headers are redacted.")
+	fmt.Fprintf(w, configHelp, configFile())
+}
+
 type subcommand struct {
-	name string
-	desc string
-	fn   func(context.Context, authToken, []string) error
+	name   string
+	desc   string
+	fn     func(context.Context, authToken, []string) error
+	noAuth bool // subcommand does not talk to the API
+	// needsProfile makes the profile be loaded for a noAuth subcommand, so
+	// it gets the profile base URL
+	needsProfile bool
 }
 
 func handleUpdate(ctx context.Context, token authToken, cliargs []string) error {
-	var urlid string
+	var urlid, titleFlag string
+	var opts transformOptions
+	var watch, dryRun, wikiLinks bool
+	var hooks imageHooks
+	uploadAll := true
+	uploaded := make(map[string]string) // cache of uploaded images shared by -watch iterations
+	linked := make(map[string]string)   // cache of wikilink targets shared by -watch iterations
+	var ifRevision int
+	var ifUpdatedAt time.Time
+	var icon *string
+	var fullWidth *bool
+	var publish, appendText, prependText bool
+	loc := docLocator{titleFlag: "find-title"}
+	td := textDiff{context: 3}
+	colorMode := "auto"
+	show := "diff"
 	fs := flag.NewFlagSet("", flag.ExitOnError)
 	fs.Usage = func() {
-		fmt.Fprintf(fs.Output(), "Usage: %s update [flags] source-document.md\n", exeName)
+		fmt.Fprintf(fs.Output(), "Usage: %s update [flags] source-document.md|-\n", exeName)
 		fs.PrintDefaults()
 	}
+	fs.StringVar(&urlid, "id", urlid, "document url|urlid, if not set, it is taken from the file front matter")
+	loc.register(fs)
+	fs.BoolVar(&opts.numberHeadings, "number-headings", opts.numberHeadings, "prefix headings with hierarchical section numbers (1., 1.1, 1.2.3)")
+	fs.StringVar(&titleFlag, "title", titleFlag, "document `title`, if not set, it is taken from the front matter or the first heading")
+	fs.BoolVar(&opts.keepH1, "keep-h1", opts.keepH1, "keep the leading H1 heading in the document text")
+	fs.BoolVar(&uploadAll, "upload-images", uploadAll, "upload images referenced by local paths as attachments, and link them instead;\n"+
+		"uploads are remembered in the "+manifestName+" file of the directory tree, if there is one, and reused")
+	fs.BoolVar(&wikiLinks, "wikilinks", wikiLinks, "convert wikilinks like [[Title]] or [[Title#Heading]] to links to documents found by their exact titles;\n"+
+		"needs API access even with -dry-run")
+	fs.Var(&hooks, "image-hook", "`pattern=command` to regenerate and upload local images matching pattern before update;\n"+
+		"command is run by shell with image path in OUTLINE_IMAGE environment variable (can be repeated)")
+	fs.BoolVar(&watch, "watch", watch, "keep running, updating document every time the source file changes")
+	fs.BoolVar(&appendText, "append", appendText, "append file content to the end of the document instead of replacing it;\n"+
+		"document title is only changed if -title flag is set")
+	fs.BoolVar(&prependText, "prepend", prependText, "insert file content at the beginning of the document instead of replacing it, see -append")
+	fs.Func("emoji", "set document emoji `icon`, empty value removes it; if not set, document icon is kept as is", func(s string) error {
+		icon = &s
+		return nil
+	})
+	fs.BoolFunc("full-width", "set document full-width display mode (-full-width=false to disable); if not set, it is kept as is", func(s string) error {
+		v, err := strconv.ParseBool(s)
+		fullWidth = &v
+		return err
+	})
+	fs.BoolVar(&publish, "publish", publish, "publish document if it is a draft")
+	fs.IntVar(&ifRevision, "if-revision", ifRevision, "only update document if its current revision number matches this one")
+	fs.Func("if-updated-at", "only update document if it was last updated at this `time` (RFC 3339)", func(s string) error {
+		var err error
+		ifUpdatedAt, err = time.Parse(time.RFC3339, s)
+		return err
+	})
+	fs.BoolVar(&dryRun, "dry-run", dryRun, "do not update document, only show what would be sent, see -show flag")
+	fs.BoolVar(&dryRun, "n", dryRun, "shorthand for -dry-run")
+	fs.StringVar(&show, "show", show, "what -dry-run shows: diff between the current document and the file,\n"+
+		"payload of the API request, or transformed markdown; only diff needs API access")
+	fs.IntVar(&td.context, "context", td.context, "number of context lines to show in the -dry-run diff")
+	fs.StringVar(&colorMode, "color", colorMode, "colorize -dry-run diff: auto, always, or never")
+	fs.Parse(cliargs)
+	if fs.NArg() == 0 {
+		return errors.New("want source document as the first positional argument")
+	}
+	if appendText && prependText {
+		return errors.New("-append and -prepend flags are mutually exclusive")
+	}
+	if watch && (appendText || prependText) {
+		return errors.New("-watch cannot be used with -append or -prepend")
+	}
+	var err error
+	if loc.set() {
+		if urlid != "" {
+			return fmt.Errorf("-id flag cannot be used with %s", loc.flags())
+		}
+		if urlid, _, err = loc.resolve(ctx, token, nil); err != nil {
+			return err
+		}
+	}
+	if td.color, err = useColor(colorMode, os.Stdout); err != nil {
+		return err
+	}
+	switch show {
+	case "diff", "payload", "markdown":
+	default:
+		return fmt.Errorf("invalid -show value %q, want diff, payload, or markdown", show)
+	}
+	push := func() error {
+		title, doc, meta, err := readDocument(fs.Arg(0), opts)
+		if err != nil {
+			return err
+		}
+		// flags take precedence over the front matter
+		docID := documentID(cmp.Or(urlid, meta.ID))
+		if docID == "" {
+			return errors.New("-id flag must be set, or the file must have id in its front matter")
+		}
+		if wikiLinks {
+			if err := resolveWikiLinks(ctx, token, doc, linked); err != nil {
+				return err
+			}
+		}
+		if fs.Arg(0) != "-" {
+			// links to local files mapped to documents point to these
+			// documents instead
+			dir := filepath.Dir(fs.Arg(0))
+			ld, err := loadLocalDocs(dir)
+			if err != nil {
+				return err
+			}
+			if dryRun && show != "diff" {
+				ld.offline() // payload and markdown are shown without API access
+			}
+			if _, err := ld.toOutline(ctx, token, doc, dir); err != nil {
+				return err
+			}
+		}
+		if !dryRun && (len(hooks) != 0 || uploadAll) {
+			if !uuidRe.MatchString(docID) && hasAny(localImages(doc)) {
+				// attachments can only be linked to the document by its id
+				d, err := documentInfo(ctx, token, docID)
+				if err != nil {
+					return err
+				}
+				docID = d.ID
+			}
+			// uploads are remembered in the manifest if the file is in
+			// a tree tracked by one, so they are reused by later runs
+			cache := uploaded
+			var root string
+			var m *manifest
+			if fs.Arg(0) != "-" {
+				if root, m, err = findManifest(filepath.Dir(fs.Arg(0))); err != nil {
+					return err
+				}
+				if root != "" {
+					cache = m.imageCache()
+				}
+			}
+			n := len(cache)
+			if err := uploadImages(ctx, token, doc, filepath.Dir(fs.Arg(0)), docID, hooks, uploadAll, cache); err != nil {
+				return err
+			}
+			if root != "" && len(cache) != n {
+				if err := m.save(root); err != nil {
+					return err
+				}
+			}
+		}
+		du := documentUpdate{
+			Id:        docID,
+			Title:     cmp.Or(titleFlag, title),
+			Text:      markdown.Format(doc),
+			Icon:      cmp.Or(icon, meta.Emoji),
+			FullWidth: cmp.Or(fullWidth, meta.FullWidth),
+			Publish:   publish || (meta.Publish != nil && *meta.Publish),
+		}
+		if appendText || prependText {
+			du.Title = titleFlag
+		}
+		switch {
+		case appendText:
+			du.Append = true
+			du.Text = "\n\n" + du.Text
+		case prependText:
+			d, err := documentInfo(ctx, token, docID)
+			if err != nil {
+				return err
+			}
+			du.Text += "\n" + d.Text
+		}
+		if !dryRun {
+			if ifRevision != 0 || !ifUpdatedAt.IsZero() {
+				d, err := documentInfo(ctx, token, docID)
+				if err != nil {
+					return err
+				}
+				if (ifRevision != 0 && d.Revision != ifRevision) || (!ifUpdatedAt.IsZero() && !d.UpdatedAt.Equal(ifUpdatedAt)) {
+					return &conflictError{doc: d}
+				}
+			}
+			d, err := updateDocument(ctx, token, du)
+			if err != nil {
+				return err
+			}
+			// in watch mode, subsequent updates are expected to be based
+			// on the document as we've just left it
+			if ifRevision != 0 {
+				ifRevision = d.Revision
+			}
+			if !ifUpdatedAt.IsZero() {
+				ifUpdatedAt = d.UpdatedAt
+			}
+			return nil
+		}
+		switch show {
+		case "payload":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(du)
+		case "markdown":
+			_, err := io.WriteString(os.Stdout, du.Text)
+			return err
+		}
+		d, err := documentInfo(ctx, token, docID)
+		if err != nil {
+			return err
+		}
+		newText := du.Text
+		if du.Append {
+			newText = d.Text + du.Text
+		}
+		if !td.write(os.Stdout, "remote", fs.Arg(0), string(documentMarkdown(d.Title, d.Text)), string(documentMarkdown(cmp.Or(du.Title, d.Title), newText))) {
+			fmt.Println("no changes")
+		}
+		return nil
+	}
+	if watch {
+		if fs.Arg(0) == "-" {
+			return errors.New("-watch cannot be used with document read from stdin")
+		}
+		return watchFile(ctx, fs.Arg(0), push)
+	}
+	return push()
+}
+
+func handleCreate(ctx context.Context, token authToken, cliargs []string) error {
+	var collection, parent, icon, titleFlag, templateID string
+	var opts transformOptions
+	var draft, publish, asTemplate, fullWidth bool
+	uploadAll := true
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s create -collection id [flags] source-document.md|-\n", exeName)
+		fmt.Fprintf(fs.Output(), "       %s create -template url|urlid [flags] [source-document.md|-]\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nDocument attributes can also be set in the YAML front matter of the file,\n"+
+			"with keys title, collection, parent, publish, emoji, and fullWidth; flags take precedence.")
+	}
+	fs.StringVar(&collection, "collection", collection, "id or name of the collection to create document in")
+	fs.StringVar(&parent, "parent", parent, "parent document url|urlid")
+	fs.BoolVar(&draft, "draft", draft, "create document as an unpublished draft")
+	fs.BoolVar(&publish, "publish", publish, "publish document right away (default, unless -draft is set)")
+	fs.BoolVar(&asTemplate, "as-template", asTemplate, "create document as a template")
+	fs.StringVar(&templateID, "template", templateID, "template `url|urlid` to create document from; if no source document is given,\n"+
+		"title and text are taken from the template, and the document is created in the template collection by default")
+	fs.StringVar(&icon, "emoji", icon, "document emoji `icon`")
+	fs.BoolVar(&fullWidth, "full-width", fullWidth, "display document in full width")
+	fs.BoolVar(&opts.numberHeadings, "number-headings", opts.numberHeadings, "prefix headings with hierarchical section numbers, see the same update flag")
+	fs.StringVar(&titleFlag, "title", titleFlag, "document `title`, if not set, it is taken from the front matter or the first heading")
+	fs.BoolVar(&opts.keepH1, "keep-h1", opts.keepH1, "keep the leading H1 heading in the document text")
+	fs.BoolVar(&uploadAll, "upload-images", uploadAll, "upload images referenced by local paths as attachments, and link them instead")
+	fs.Parse(cliargs)
+	if fs.NArg() == 0 && templateID == "" {
+		return errors.New("want source document as the first positional argument")
+	}
+	if draft && publish {
+		return errors.New("-draft and -publish flags are mutually exclusive")
+	}
+	var err error
+	var tpl *document
+	if templateID != "" {
+		if tpl, err = documentInfo(ctx, token, documentID(templateID)); err != nil {
+			return err
+		}
+		templateID = tpl.ID
+	}
+	var title string
+	var doc *markdown.Document
+	meta := new(frontMatter)
+	if fs.NArg() != 0 {
+		if title, doc, meta, err = readDocument(fs.Arg(0), opts); err != nil {
+			return err
+		}
+	} else {
+		var p markdown.Parser
+		title, doc = tpl.Title, p.Parse(tpl.Text)
+	}
+	// flags take precedence over the front matter
+	collection = cmp.Or(collection, meta.Collection)
+	parent = cmp.Or(parent, meta.Parent)
+	if collection == "" && parent == "" {
+		collection = defaultCollection
+	}
+	if collection == "" && parent == "" && tpl != nil {
+		collection = tpl.CollectionID
+	}
+	if collection == "" && parent == "" {
+		return errors.New("either -collection or -parent flag must be set, or the file front matter must have one of them")
+	}
+	if collection, err = collectionID(ctx, token, collection); err != nil {
+		return err
+	}
+	if parent != "" {
+		parent = documentID(parent)
+	}
+	if !draft && !publish && meta.Publish != nil {
+		draft = !*meta.Publish
+	}
+	if icon == "" && meta.Emoji != nil {
+		icon = *meta.Emoji
+	}
+	if !fullWidth && meta.FullWidth != nil {
+		fullWidth = *meta.FullWidth
+	}
+	d, err := createDocument(ctx, token, newDocument{
+		Title:      cmp.Or(titleFlag, title),
+		Text:       markdown.Format(doc),
+		Collection: collection,
+		Parent:     parent,
+		Publish:    !draft,
+		Template:   asTemplate,
+		TemplateID: templateID,
+		Icon:       icon,
+		FullWidth:  fullWidth,
+	})
+	if err != nil {
+		return err
+	}
+	if uploadAll && hasAny(localImages(doc)) {
+		// attachments need document id, so upload them once it is created
+		if err := uploadImages(ctx, token, doc, filepath.Dir(fs.Arg(0)), d.ID, nil, true, nil); err != nil {
+			return err
+		}
+		if d, err = updateDocument(ctx, token, documentUpdate{Id: d.ID, Text: markdown.Format(doc)}); err != nil {
+			return err
+		}
+	}
+	if jsonOutput {
+		return writeJSON("", summarize(d))
+	}
+	fmt.Printf("%s\t%s\n", d.ID, webURL(d.URL))
+	return nil
+}
+
+func handleDiff(ctx context.Context, token authToken, cliargs []string) error {
+	var urlid, titleFlag string
+	var opts transformOptions
+	loc := docLocator{titleFlag: "find-title"}
+	td := textDiff{context: 3}
+	colorMode := "auto"
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s diff [flags] source-document.md|-\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nLocal file is transformed the same way update does it. Exit status is non-zero if they differ.")
+	}
 	fs.StringVar(&urlid, "id", urlid, "document url|urlid")
+	loc.register(fs)
+	fs.BoolVar(&opts.numberHeadings, "number-headings", opts.numberHeadings, "prefix headings with hierarchical section numbers, see the same update flag")
+	fs.StringVar(&titleFlag, "title", titleFlag, "document `title`, see the same update flag")
+	fs.BoolVar(&opts.keepH1, "keep-h1", opts.keepH1, "keep the leading H1 heading in the document text")
+	fs.IntVar(&td.context, "context", td.context, "number of context lines to show")
+	fs.StringVar(&colorMode, "color", colorMode, "colorize diff: auto, always, or never")
 	fs.Parse(cliargs)
 	if fs.NArg() == 0 {
 		return errors.New("want source document as the first positional argument")
 	}
-	if urlid == "" {
-		return errors.New("-id flag must be set")
+	var err error
+	if td.color, err = useColor(colorMode, os.Stdout); err != nil {
+		return err
 	}
-	if i := strings.LastIndexByte(urlid, '-'); i != -1 {
-		urlid = urlid[i+1:]
+	if loc.set() {
+		if urlid != "" {
+			return fmt.Errorf("-id flag cannot be used with %s", loc.flags())
+		}
+		if urlid, _, err = loc.resolve(ctx, token, nil); err != nil {
+			return err
+		}
 	}
-	data, err := os.ReadFile(fs.Arg(0))
+	title, doc, meta, err := readDocument(fs.Arg(0), opts)
 	if err != nil {
 		return err
 	}
-	var p markdown.Parser
-	doc := p.Parse(string(data))
-	title := docTitle(doc)
-	dropLeadingH1(doc)
-	rewriteHeadingLinks(doc)
-	req := struct {
-		Id    string `json:"id"`
-		Title string `json:"title,omitempty"`
-		Text  string `json:"text"`
-	}{
-		Id:    urlid,
-		Title: title,
-		Text:  markdown.Format(doc),
+	if urlid = cmp.Or(urlid, meta.ID); urlid == "" {
+		return errors.New("-id flag must be set, or the file must have id in its front matter")
 	}
-	var res struct{}
-	return doApiRequest(ctx, req, &res, token, "https://app.getoutline.com/api/documents.update")
+	d, err := documentInfo(ctx, token, documentID(urlid))
+	if err != nil {
+		return err
+	}
+	if td.write(os.Stdout, "remote", fs.Arg(0), string(documentMarkdown(d.Title, d.Text)), string(documentMarkdown(cmp.Or(titleFlag, title), markdown.Format(doc)))) {
+		return errors.New("document differs from the file")
+	}
+	return nil
 }
 
 func handleGet(ctx context.Context, token authToken, cliargs []string) error {
 	var dstFile string
+	var loc docLocator
+	var withChildren, combine bool
+	opts := getOptions{format: "markdown", pdfCommand: cmp.Or(os.Getenv("OUTLINE_PDF_COMMAND"), defaultPDFCommand)}
+	jobs := 4
 	fs := flag.NewFlagSet("", flag.ExitOnError)
 	fs.Usage = func() {
 		fmt.Fprintf(fs.Output(), "Usage: %s get [flags] url|urlid\n", exeName)
+		fmt.Fprintf(fs.Output(), "       %s get -o directory [flags] url|urlid... (or - to read them from stdin)\n", exeName)
+		fmt.Fprintf(fs.Output(), "       %s get -o directory -with-children [flags] url|urlid\n", exeName)
+		fmt.Fprintf(fs.Output(), "       %s get -combine [flags] url|urlid\n", exeName)
 		fs.PrintDefaults()
 	}
-	fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
-	fs.Parse(cliargs)
-	if fs.NArg() == 0 {
+	fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout;\n"+
+		"when getting multiple documents, directory to save them to, named after document titles")
+	fs.BoolVar(&opts.numbered, "number-headings", opts.numbered, "strip hierarchical section numbers from headings, see the same update flag")
+	fs.StringVar(&opts.assetsDir, "assets", opts.assetsDir, "`directory` to download images attached to the document to, rewriting links to them")
+	fs.BoolVar(&opts.withMeta, "frontmatter", opts.withMeta, "prepend YAML front matter with document id, collection, parent, and other attributes\nthat update and create understand")
+	fs.BoolVar(&opts.comments, "comments", opts.comments, "append document comments as the \"Comments\" section, or as the comments field in the JSON output")
+	fs.StringVar(&opts.format, "format", opts.format, "output `format`: markdown, html, or pdf")
+	fs.StringVar(&opts.pdfCommand, "pdf-command", opts.pdfCommand, "shell `command` converting HTML read from stdin to PDF written to stdout,\n"+
+		"used for pdf format; run in the directory of the -o file, so it can find images saved with -assets;\n"+
+		"defaults to OUTLINE_PDF_COMMAND environment variable if set")
+	fs.IntVar(&jobs, "jobs", jobs, "number of documents to download in parallel when getting multiple documents")
+	fs.BoolVar(&withChildren, "with-children", withChildren, "also get all nested child documents of the document into the -o directory,\n"+
+		"child documents are saved to the directory named after their parent document")
+	fs.BoolVar(&combine, "combine", combine, "combine the document and all its nested child documents into a single markdown file,\n"+
+		"with headings shifted by nesting depth and links between the documents rewritten to local anchors")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	switch opts.format {
+	case "markdown":
+	case "html", "pdf":
+		if opts.withMeta {
+			return errors.New("-frontmatter flag can only be used with markdown format")
+		}
+		if opts.format == "pdf" && (dstFile == "" || dstFile == "-") {
+			return errors.New("-o flag must be set for pdf format")
+		}
+	default:
+		return fmt.Errorf("invalid -format value %q, want markdown, html, or pdf", opts.format)
+	}
+	if combine {
+		switch {
+		case withChildren:
+			return errors.New("-combine and -with-children flags are mutually exclusive")
+		case opts.withMeta || opts.comments:
+			return errors.New("-frontmatter and -comments flags cannot be used with -combine")
+		case len(args) != loc.docArgs() || len(args) == 1 && args[0] == "-":
+			return errors.New("-combine flag needs exactly one document")
+		}
+		urlid, _, err := loc.resolve(ctx, token, args)
+		if err != nil {
+			return err
+		}
+		doc, err := combineDocuments(ctx, token, urlid, opts)
+		if err != nil {
+			return err
+		}
+		baseDir := "."
+		if dstFile != "" && dstFile != "-" {
+			baseDir = filepath.Dir(dstFile)
+		}
+		if opts.assetsDir != "" {
+			if err := downloadImages(ctx, token, doc, opts.assetsDir, baseDir); err != nil {
+				return err
+			}
+		}
+		var title string
+		if h, ok := doc.Blocks[0].(*markdown.Heading); ok {
+			title = inlinesText(h.Text.Inline)
+		}
+		out, err := opts.convert(ctx, title, []byte(markdown.Format(doc)), baseDir)
+		if err != nil {
+			return err
+		}
+		return writeResult(dstFile, out)
+	}
+	if withChildren {
+		if len(args) != loc.docArgs() || len(args) == 1 && args[0] == "-" {
+			return errors.New("-with-children flag needs exactly one document")
+		}
+		if dstFile == "" || dstFile == "-" {
+			return errors.New("-o flag must be set to a directory when getting documents with children")
+		}
+		urlid, _, err := loc.resolve(ctx, token, args)
+		if err != nil {
+			return err
+		}
+		return getDocumentTree(ctx, token, urlid, dstFile, opts)
+	}
+	if loc.set() || len(args) == 1 && args[0] != "-" {
+		if len(args) != loc.docArgs() {
+			return fmt.Errorf("%s flags cannot be used when getting multiple documents", loc.flags())
+		}
+		urlid, _, err := loc.resolve(ctx, token, args)
+		if err != nil {
+			return err
+		}
+		return getDocument(ctx, token, urlid, opts, func(*document) string { return dstFile })
+	}
+	if len(args) == 0 {
 		return errors.New("want document url/urlid as the first positional argument")
 	}
-	urlid := fs.Arg(0)
-	if i := strings.LastIndexByte(urlid, '-'); i != -1 {
-		urlid = urlid[i+1:]
+	if dstFile == "" || dstFile == "-" {
+		return errors.New("-o flag must be set to a directory when getting multiple documents")
 	}
-	req := struct {
-		Id string `json:"id"`
-	}{Id: urlid}
-	var res struct {
-		Data struct {
-			Title string `json:"title"`
-			Text  string `json:"text"`
-		} `json:"data"`
+	if opts.assetsDir != "" {
+		return errors.New("-assets flag cannot be used when getting multiple documents")
+	}
+	if len(args) == 1 {
+		args = nil
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			if s := strings.TrimSpace(sc.Text()); s != "" {
+				args = append(args, s)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return err
+		}
 	}
-	if err := doApiRequest(ctx, req, &res, token, "https://app.getoutline.com/api/documents.info"); err != nil {
+	if err := os.MkdirAll(dstFile, 0777); err != nil {
 		return err
 	}
-	var buf bytes.Buffer
-	fmt.Fprintf(&buf, "# %s\n\n", res.Data.Title)
-	fmt.Fprintln(&buf, res.Data.Text)
+	var mu sync.Mutex
+	names := make(map[string]struct{})
+	dst := func(d *document) string {
+		mu.Lock()
+		defer mu.Unlock()
+		return filepath.Join(dstFile, uniqueName(names, fileName(d.Title))+opts.ext())
+	}
+	ids := make(chan int)
+	errs := make([]error, len(args))
+	var wg sync.WaitGroup
+	for range max(jobs, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range ids {
+				if err := getDocument(ctx, token, documentID(args[i]), opts, dst); err != nil {
+					errs[i] = fmt.Errorf("%s: %w", args[i], err)
+				}
+			}
+		}()
+	}
+	for i := range args {
+		ids <- i
+	}
+	close(ids)
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// getDocumentTree saves the document with all its nested child documents
+// into the directory dir, child documents of each document are saved to the
+// directory named after it
+func getDocumentTree(ctx context.Context, token authToken, urlid, dir string, opts getOptions) error {
+	var walk func(dir, urlid string, names map[string]struct{}) error
+	walk = func(dir, urlid string, names map[string]struct{}) error {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return err
+		}
+		var docID, name string
+		err := getDocument(ctx, token, urlid, opts, func(d *document) string {
+			docID, name = d.ID, uniqueName(names, fileName(d.Title))
+			return filepath.Join(dir, name+opts.ext())
+		})
+		if err != nil {
+			return err
+		}
+		children := make(map[string]struct{})
+		for d, err := range childDocuments(ctx, token, docID) {
+			if err != nil {
+				return err
+			}
+			if err := walk(filepath.Join(dir, name), d.ID, children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(dir, urlid, make(map[string]struct{}))
+}
+
+// childDocuments iterates over direct child documents of the document with
+// the given id
+func childDocuments(ctx context.Context, token authToken, docID string) iter.Seq2[document, error] {
+	return paginate[document](ctx, token, apiURL("documents.list"), 0, func(p pagination) any {
+		return struct {
+			pagination
+			Parent string `json:"parentDocumentId"`
+		}{pagination: p, Parent: docID}
+	})
+}
+
+// getOptions control how get transforms and saves documents
+type getOptions struct {
+	numbered  bool   // strip heading numbers
+	assetsDir string // directory to download images to
+	withMeta  bool   // prepend front matter
+	comments  bool   // append comments section
+	format    string // markdown, html, or pdf
+	// shell command converting HTML to PDF for pdf format
+	pdfCommand string
+}
+
+// ext returns file name extension for the documents saved
+func (o getOptions) ext() string {
+	switch o.format {
+	case "html":
+		return ".html"
+	case "pdf":
+		return ".pdf"
+	}
+	return ".md"
+}
+
+// convert converts document markdown into the output format, dir is the
+// directory the result is saved to
+func (o getOptions) convert(ctx context.Context, title string, md []byte, dir string) ([]byte, error) {
+	switch o.format {
+	case "html":
+		return []byte(documentHTML(string(md), slugRegular)), nil
+	case "pdf":
+		return renderPDF(ctx, o.pdfCommand, title, documentHTML(string(md), slugRegular), dir)
+	}
+	return md, nil
+}
+
+// getDocument downloads document and saves it to the file returned by the
+// dst function, which is called once the document is fetched
+func getDocument(ctx context.Context, token authToken, urlid string, opts getOptions, dst func(*document) string) error {
+	d, err := documentInfo(ctx, token, urlid)
+	if err != nil {
+		return err
+	}
+	dstFile := dst(d)
+	baseDir := "." // directory the document is saved to
 	if dstFile != "" && dstFile != "-" {
-		return os.WriteFile(dstFile, buf.Bytes(), 0666)
+		baseDir = filepath.Dir(dstFile)
 	}
-	_, err := os.Stdout.Write(buf.Bytes())
-	return err
+	var p markdown.Parser
+	doc := p.Parse(d.Text)
+	if opts.numbered {
+		stripHeadingNumbers(doc)
+	}
+	// links to headings are rewritten to the local style; document is only
+	// reformatted if it was changed in any way
+	changed := restoreHeadingLinks(doc) || opts.numbered
+	if dstFile != "" && dstFile != "-" && opts.ext() == ".md" {
+		// links to documents mapped to local files point to these files
+		// instead, so the saved directory is navigable locally
+		ld, err := loadLocalDocs(baseDir)
+		if err != nil {
+			return err
+		}
+		ok, err := ld.toLocal(ctx, token, doc, baseDir)
+		if err != nil {
+			return err
+		}
+		changed = changed || ok
+	}
+	if opts.assetsDir != "" {
+		if err := downloadImages(ctx, token, doc, opts.assetsDir, baseDir); err != nil {
+			return err
+		}
+		changed = true
+	}
+	text := d.Text
+	if changed {
+		text = markdown.Format(doc)
+	}
+	var threads []*comment
+	if opts.comments {
+		if threads, err = commentThreads(ctx, token, d.ID); err != nil {
+			return err
+		}
+	}
+	if jsonOutput {
+		ds := summarize(d)
+		ds.Text = text
+		ds.Comments = threads
+		return writeJSON(dstFile, ds)
+	}
+	if len(threads) != 0 {
+		var buf strings.Builder
+		buf.WriteString(strings.TrimRight(text, "\n"))
+		buf.WriteString("\n\n## Comments\n\n")
+		writeComments(&buf, threads)
+		text = strings.TrimRight(buf.String(), "\n")
+	}
+	out := documentMarkdown(d.Title, text)
+	if opts.withMeta {
+		meta, err := documentFrontMatter(d).encode()
+		if err != nil {
+			return err
+		}
+		out = append(meta, out...)
+	}
+	if out, err = opts.convert(ctx, d.Title, out, baseDir); err != nil {
+		return err
+	}
+	return writeResult(dstFile, out)
+}
+
+// documentMarkdown returns document text in the form saved by get: with
+// a document title as the leading H1 heading.
+func documentMarkdown(title, text string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n\n", title)
+	fmt.Fprintln(&buf, text)
+	return buf.Bytes()
+}
+
+// documentHTML renders markdown text as HTML, giving headings ids generated by
+// the slug function, so links to headings work. Unlike elsewhere,
+// GitHub-flavored extensions like tables are parsed, so they are rendered
+// properly.
+func documentHTML(text string, slug func(string) string) string {
+	p := markdown.Parser{Table: true, Strikethrough: true, TaskList: true, AutoLinkText: true}
+	doc := p.Parse(text)
+	slugs := headingSlugs(doc, slug)
+	var i int
+	for _, b := range doc.Blocks {
+		if h, ok := b.(*markdown.Heading); ok {
+			h.ID = slugs[i]
+			i++
+		}
+	}
+	return markdown.ToHTML(doc)
 }
 
 func handleSearch(ctx context.Context, token authToken, cliargs []string) error {
-	var dstFile string
+	var dstFile, user string
+	collection := defaultCollection
 	limit := 25
 	offset := 0
 	status := "published"
@@ -153,89 +938,424 @@ func handleSearch(ctx context.Context, token authToken, cliargs []string) error
 	fs.IntVar(&limit, "limit", limit, "maximum number of results to return")
 	fs.IntVar(&offset, "offset", offset, "number of results to skip")
 	fs.StringVar(&status, "status", status, "document status to filter by (published, draft, archived)")
+	fs.StringVar(&collection, "collection", collection, "collection id or name to limit search to")
+	fs.StringVar(&user, "user", user, "user id to limit search to documents edited by this user")
 	fs.Parse(cliargs)
 	if fs.NArg() == 0 {
 		return errors.New("no query")
 	}
-	query := strings.Join(fs.Args(), " ")
-	req := struct {
-		Limit  int      `json:"limit"`
-		Offset int      `json:"offset"`
-		Query  string   `json:"query"`
-		Status []string `json:"statusFilter"`
-	}{Limit: limit, Offset: offset, Query: query, Status: []string{status}}
-	var res struct {
-		Data []struct {
-			Context  string `json:"context"`
-			Document struct {
-				Title string `json:"title"`
-				UrlID string `json:"urlId"`
-			} `json:"document"`
-		} `json:"data"`
-	}
-	if err := doApiRequest(ctx, req, &res, token, "https://app.getoutline.com/api/documents.search"); err != nil {
+	collection, err := collectionID(ctx, token, collection)
+	if err != nil {
 		return err
 	}
+	results, err := searchDocuments(ctx, token, searchQuery{
+		Limit:      limit,
+		Offset:     offset,
+		Query:      strings.Join(fs.Args(), " "),
+		Status:     []string{status},
+		Collection: collection,
+		User:       user,
+	})
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		out := []docSummary{}
+		for _, item := range results {
+			ds := summarize(&item.Document)
+			ds.Context = item.Context
+			out = append(out, ds)
+		}
+		return writeJSON(dstFile, out)
+	}
 	var buf bytes.Buffer
-	fmt.Fprintf(&buf, "%d results\n\n", len(res.Data))
-	for _, item := range res.Data {
+	fmt.Fprintf(&buf, "%d results\n\n", len(results))
+	for _, item := range results {
 		fmt.Fprintf(&buf, "# %s\nURL ID: `%s`\nContext: %s\n\n", item.Document.Title, item.Document.UrlID, item.Context)
 	}
+	return writeResult(dstFile, buf.Bytes())
+}
+
+type searchQuery struct {
+	Limit      int      `json:"limit"`
+	Offset     int      `json:"offset"`
+	Query      string   `json:"query"`
+	Status     []string `json:"statusFilter,omitempty"`
+	Collection string   `json:"collectionId,omitempty"`
+	User       string   `json:"userId,omitempty"`
+}
+
+type searchResult struct {
+	Context  string   `json:"context"`
+	Document document `json:"document"`
+}
+
+func searchDocuments(ctx context.Context, token authToken, q searchQuery) ([]searchResult, error) {
+	return apiRequest[[]searchResult](ctx, token, apiURL("documents.search"), q)
+}
+
+func handleList(ctx context.Context, token authToken, cliargs []string) error {
+	var dstFile string
+	collection := defaultCollection
+	var limit int
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s list [flags]\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+	fs.StringVar(&collection, "collection", collection, "collection id or name to list documents from, if not set, documents from all collections are listed")
+	fs.IntVar(&limit, "limit", limit, "maximum number of documents to return, 0 means no limit")
+	fs.Parse(cliargs)
+	collection, err := collectionID(ctx, token, collection)
+	if err != nil {
+		return err
+	}
+	return writeDocuments(dstFile, listDocuments(ctx, token, collection, limit))
+}
+
+func handleDrafts(ctx context.Context, token authToken, cliargs []string) error {
+	var dstFile string
+	collection := defaultCollection
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s drafts [flags]\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+	fs.StringVar(&collection, "collection", collection, "collection id or name to list drafts from")
+	fs.Parse(cliargs)
+	collection, err := collectionID(ctx, token, collection)
+	if err != nil {
+		return err
+	}
+	drafts := paginate[document](ctx, token, apiURL("documents.drafts"), 0, func(p pagination) any {
+		return struct {
+			pagination
+			Collection string `json:"collectionId,omitempty"`
+		}{pagination: p, Collection: collection}
+	})
+	return writeDocuments(dstFile, drafts)
+}
+
+// listDocuments iterates over documents.list results, transparently requesting
+// subsequent pages. If limit is positive, at most limit documents are
+// returned. Iteration stops after the first error.
+func listDocuments(ctx context.Context, token authToken, collection string, limit int) iter.Seq2[document, error] {
+	return paginate[document](ctx, token, apiURL("documents.list"), limit, func(p pagination) any {
+		return struct {
+			pagination
+			Collection string `json:"collectionId,omitempty"`
+			Sort       string `json:"sort"`
+			Direction  string `json:"direction"`
+		}{pagination: p, Collection: collection, Sort: "updatedAt", Direction: "DESC"}
+	})
+}
+
+// pagination holds the paging attributes common to all list-style API
+// requests, request types embed it.
+type pagination struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// paginate iterates over results of the list-style endpoint, transparently
+// requesting subsequent pages. Function newReq must return request object for
+// a given page. If limit is positive, at most limit results are returned.
+// Iteration stops after the first error.
+func paginate[T any](ctx context.Context, token authToken, endpoint string, limit int, newReq func(pagination) any) iter.Seq2[T, error] {
+	const pageSize = 100 // maximum page size the API allows
+	return func(yield func(T, error) bool) {
+		var seen int
+		for offset := 0; ; offset += pageSize {
+			items, err := apiRequest[[]T](ctx, token, endpoint, newReq(pagination{Limit: pageSize, Offset: offset}))
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, v := range items {
+				if !yield(v, nil) {
+					return
+				}
+				if seen++; limit > 0 && seen >= limit {
+					return
+				}
+			}
+			if len(items) < pageSize {
+				return
+			}
+		}
+	}
+}
+
+// documentInfo fetches a single document by its id or urlId
+func documentInfo(ctx context.Context, token authToken, id string) (*document, error) {
+	req := struct {
+		Id string `json:"id"`
+	}{Id: id}
+	return apiRequest[*document](ctx, token, apiURL("documents.info"), req)
+}
+
+// document holds a subset of document attributes returned by the API
+type document struct {
+	ID           string     `json:"id"`
+	UrlID        string     `json:"urlId"`
+	URL          string     `json:"url"` // path relative to the Outline instance root
+	Title        string     `json:"title"`
+	Text         string     `json:"text"`
+	CollectionID string     `json:"collectionId"`
+	ParentID     string     `json:"parentDocumentId"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	PublishedAt  *time.Time `json:"publishedAt"` // nil for drafts
+	ArchivedAt   *time.Time `json:"archivedAt"`
+	Template     bool       `json:"template"`
+	CreatedBy    user       `json:"createdBy"`
+	UpdatedBy    user       `json:"updatedBy"`
+	Revision     int        `json:"revision"`
+	Icon         string     `json:"icon"`
+	Emoji        string     `json:"emoji"` // older API versions use it instead of icon
+	FullWidth    bool       `json:"fullWidth"`
+}
+
+// icon returns document emoji/icon
+func (d *document) icon() string { return cmp.Or(d.Icon, d.Emoji) }
+
+// user holds a subset of user attributes returned by the API
+type user struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// hasAny reports whether sequence yields any values
+func hasAny[T any](seq iter.Seq[T]) bool {
+	for range seq {
+		return true
+	}
+	return false
+}
+
+// webURL turns path relative to the Outline instance root, like document url
+// attribute, into an absolute url
+func webURL(path string) string { return baseURL + path }
+
+// documentID extracts document urlId from the document url. Plain urlIds and
+// full document UUIDs are returned unchanged.
+func documentID(s string) string {
+	if uuidRe.MatchString(s) {
+		return s
+	}
+	if i := strings.LastIndexByte(s, '-'); i != -1 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// documentLink parses link to a document of this Outline instance, either
+// absolute or relative, and returns the document urlId or id, and the slug of
+// the heading linked to, if any. It reports whether link points to a document.
+func documentLink(link string) (id, heading string, ok bool) {
+	s, ok := strings.CutPrefix(strings.TrimPrefix(link, webURL("")), "/doc/")
+	if !ok {
+		return "", "", false
+	}
+	s, heading, _ = strings.Cut(s, "#")
+	return documentID(s), heading, true
+}
+
+var uuidRe = regexp.MustCompile(`^[[:xdigit:]]{8}-[[:xdigit:]]{4}-[[:xdigit:]]{4}-[[:xdigit:]]{4}-[[:xdigit:]]{12}$`)
+
+// parseInterspersed parses args allowing flags to follow positional arguments,
+// and returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var out []string
+	for {
+		fs.Parse(args)
+		if args = fs.Args(); len(args) == 0 {
+			return out
+		}
+		out = append(out, args[0])
+		args = args[1:]
+	}
+}
+
+// writeResult saves data to dstFile, or writes it to stdout if dstFile is
+// either empty or "-".
+func writeResult(dstFile string, data []byte) error {
 	if dstFile != "" && dstFile != "-" {
-		return os.WriteFile(dstFile, buf.Bytes(), 0666)
+		return os.WriteFile(dstFile, data, 0666)
 	}
-	_, err := os.Stdout.Write(buf.Bytes())
+	_, err := os.Stdout.Write(data)
 	return err
 }
 
-func doApiRequest(ctx context.Context, reqObject, respObjectPtr any, token authToken, endpoint string) error {
-	if reflect.ValueOf(respObjectPtr).Kind() != reflect.Pointer {
-		panic("doApiRequest expects respObjectPtr to be a pointer")
-	}
+// apiRequest calls the API method at the endpoint with the req request
+// object, and returns the data the response holds.
+func apiRequest[Resp, Req any](ctx context.Context, token authToken, endpoint string, reqObject Req) (Resp, error) {
+	var zero Resp
 	body, err := json.Marshal(reqObject)
 	if err != nil {
-		return err
+		return zero, err
 	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
-		return err
+		return zero, err
 	}
+	setCommonHeaders(req.Header)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", token.bearer())
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := doRetry(req)
 	if err != nil {
-		return err
+		return zero, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusBadRequest {
-			var msg json.RawMessage
-			if json.NewDecoder(resp.Body).Decode(&msg) == nil {
-				return &badRequestError{data: string(msg)}
-			}
-		}
-		return fmt.Errorf("unexpected status: %s", resp.Status)
+		return zero, responseError(resp)
 	}
 	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
-		return fmt.Errorf("unexpected content-type: %s", ct)
+		return zero, fmt.Errorf("unexpected content-type: %s", ct)
 	}
-	dec := json.NewDecoder(resp.Body)
-	return dec.Decode(respObjectPtr)
+	if resp.ContentLength > maxResponseSize {
+		return zero, fmt.Errorf("response body size %d exceeds %d bytes limit", resp.ContentLength, maxResponseSize)
+	}
+	data, err := readLimited(resp.Body, maxResponseSize)
+	if err != nil {
+		return zero, err
+	}
+	if err := checkJSONDepth(data, maxJSONDepth); err != nil {
+		return zero, err
+	}
+	var res struct {
+		Data Resp `json:"data"`
+	}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return zero, err
+	}
+	return res.Data, nil
+}
+
+// apiCall calls the API method at the endpoint with the req request object,
+// discarding the response data
+func apiCall[Req any](ctx context.Context, token authToken, endpoint string, req Req) error {
+	_, err := apiRequest[json.RawMessage](ctx, token, endpoint, req)
+	return err
+}
+
+// extraHeaders are sent with every API request, they are configured with
+// the OUTLINE_HEADERS environment variable holding newline-separated
+// "Name: value" pairs.
+var extraHeaders http.Header
+
+func parseHeaders(s string) (http.Header, error) {
+	h := make(http.Header)
+	for line := range strings.Lines(s) {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if k = strings.TrimSpace(k); !ok || k == "" || strings.ContainsAny(k, " \t") {
+			return nil, fmt.Errorf("invalid header line %q, want \"Name: value\"", line)
+		}
+		h.Add(k, strings.TrimSpace(v))
+	}
+	return h, nil
+}
+
+// setCommonHeaders sets User-Agent and user-configured extra headers
+func setCommonHeaders(h http.Header) {
+	h.Set("User-Agent", userAgent())
+	for k, vv := range extraHeaders {
+		h[k] = append([]string(nil), vv...)
+	}
+}
+
+func userAgent() string {
+	return "outline-cli/" + versionString() + " (" + runtime.GOOS + "/" + runtime.GOARCH + "; +https://github.com/artyom/outline)"
 }
 
 type authToken string
 
 func (t authToken) bearer() string { return "Bearer " + string(t) }
 
-type badRequestError struct {
-	data string
+// transformOptions control optional transformations of a local document
+// before it is uploaded
+type transformOptions struct {
+	numberHeadings bool
+	keepH1         bool // keep the leading H1 heading in the document body
+}
+
+// readDocument reads markdown file, or stdin if name is "-", and transforms it
+// the same way update does, see parseDocument. It returns the document title, the document, and
+// metadata from the file front matter, which is never nil. If the document
+// has no headings, its title is derived from the file name.
+func readDocument(name string, opts transformOptions) (string, *markdown.Document, *frontMatter, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return "", nil, nil, err
+	}
+	meta, _ := splitFrontMatter(data)
+	fm, err := parseFrontMatter(meta)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("%s: %w", name, err)
+	}
+	title, doc := parseDocument(data, opts)
+	if fm.Title != "" {
+		title = fm.Title
+	}
+	if title == "" && name != "-" {
+		title = fileTitle(name)
+	}
+	return title, doc, fm, nil
+}
+
+// fileTitle returns document title derived from its file name, for documents
+// without headings
+func fileTitle(name string) string {
+	return strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
 }
 
-func (e *badRequestError) Error() string {
-	if e.data != "" {
-		return "Bad request: " + e.data
+// parseDocument parses markdown source into a document ready to be uploaded
+// to Outline: front matter and the leading H1 heading are dropped, and links
+// to headings are rewritten to Outline style. Optional transformations are
+// applied according to opts. It also returns document title taken from the
+// first heading.
+func parseDocument(data []byte, opts transformOptions) (title string, doc *markdown.Document) {
+	_, data = splitFrontMatter(data)
+	var p markdown.Parser
+	doc = p.Parse(string(data))
+	title = docTitle(doc)
+	if !opts.keepH1 {
+		dropLeadingH1(doc)
+	}
+	rewriteHeadingLinks(doc)
+	if opts.numberHeadings {
+		numberHeadings(doc)
+	}
+	return title, doc
+}
+
+// conflictError is returned when document was changed remotely since the
+// revision the local change is based on
+type conflictError struct {
+	doc *document
+}
+
+func (e *conflictError) Error() string {
+	by := e.doc.UpdatedBy.Name
+	if by == "" {
+		by = "unknown user"
 	}
-	return "Bad request"
+	return fmt.Sprintf("document %q was changed remotely: it is now at revision %d, updated at %s by %s; "+
+		"review remote changes before overwriting them",
+		e.doc.Title, e.doc.Revision, e.doc.UpdatedAt.Format(time.RFC3339), by)
 }
 
 func docTitle(doc *markdown.Document) string {
@@ -263,6 +1383,12 @@ func inlinesText(inl markdown.Inlines) string {
 			b.WriteString(inlinesText(x.Inner))
 		case *markdown.Emph:
 			b.WriteString(inlinesText(x.Inner))
+		case *markdown.Del:
+			b.WriteString(inlinesText(x.Inner))
+		case *markdown.Link:
+			b.WriteString(inlinesText(x.Inner))
+		case *markdown.Code:
+			b.WriteString(x.Text)
 		}
 	}
 	return b.String()
@@ -281,47 +1407,118 @@ func dropLeadingH1(doc *markdown.Document) {
 // github|vscode-compatible to Outline-compatible style.
 func rewriteHeadingLinks(doc *markdown.Document) {
 	slugs := make(map[string]string) // regular slug to outline-style slug
+	outline := headingSlugs(doc, slugOutline)
+	for i, s := range headingSlugs(doc, slugRegular) {
+		slugs["#"+s] = "#" + outline[i]
+	}
+	replaceLinks(doc, slugs)
+}
+
+// restoreHeadingLinks is the inverse of rewriteHeadingLinks: it rewrites links
+// to document subsections from Outline style to github|vscode-compatible one,
+// so they work in a local copy of the document. It reports whether any link
+// was changed.
+func restoreHeadingLinks(doc *markdown.Document) bool {
+	slugs := make(map[string]string) // outline-style slug to regular slug
+	regular := headingSlugs(doc, slugRegular)
+	for i, s := range headingSlugs(doc, slugOutline) {
+		slugs["#"+s] = "#" + regular[i]
+	}
+	return replaceLinks(doc, slugs)
+}
+
+// localText returns document text with links to headings rewritten by
+// restoreHeadingLinks, the text is only reformatted if any link was changed
+func localText(text string) string {
+	var p markdown.Parser
+	doc := p.Parse(text)
+	if !restoreHeadingLinks(doc) {
+		return text
+	}
+	return markdown.Format(doc)
+}
+
+// headingSlugs returns slugs of the top-level document headings, in order,
+// generated by the slug function. Slugs of headings with the same text are
+// disambiguated the way both github and Outline do it: second and later
+// occurrences get "-1", "-2", etc. suffixes.
+func headingSlugs(doc *markdown.Document, slug func(string) string) []string {
+	var out []string
+	seen := make(map[string]int)
 	for _, b := range doc.Blocks {
 		h, ok := b.(*markdown.Heading)
 		if !ok {
 			continue
 		}
-		text := inlinesText(h.Text.Inline)
-		slugs["#"+slugRegular(text)] = "#" + slugOutline(text)
+		s := slug(inlinesText(h.Text.Inline))
+		if n := seen[s]; n != 0 {
+			out = append(out, s+"-"+strconv.Itoa(n))
+		} else {
+			out = append(out, s)
+		}
+		seen[s]++
 	}
-	if len(slugs) == 0 {
-		return
+	return out
+}
+
+// replaceLinks replaces document link URLs found in the urls map with the
+// corresponding values, and reports whether any link was changed.
+func replaceLinks(doc *markdown.Document, urls map[string]string) bool {
+	if len(urls) == 0 {
+		return false
 	}
+	var changed bool
 	for link := range docLinks(doc) {
-		if u, ok := slugs[link.URL]; ok {
+		if u, ok := urls[link.URL]; ok && u != link.URL {
 			link.URL = u
+			changed = true
 		}
 	}
+	return changed
 }
 
 func docLinks(doc *markdown.Document) iter.Seq[*markdown.Link] {
-	var walkLinks func(markdown.Inlines, func(*markdown.Link) bool) bool
-	walkLinks = func(inlines markdown.Inlines, yield func(*markdown.Link) bool) bool {
+	return func(yield func(*markdown.Link) bool) {
+		for inl := range docInlines(doc) {
+			if link, ok := inl.(*markdown.Link); ok && !yield(link) {
+				return
+			}
+		}
+	}
+}
+
+// docInlines iterates over inline elements of the document, descending into
+// container inlines like emphasis or links after yielding the container itself.
+func docInlines(doc *markdown.Document) iter.Seq[markdown.Inline] {
+	var walkInlines func(markdown.Inlines, func(markdown.Inline) bool) bool
+	walkInlines = func(inlines markdown.Inlines, yield func(markdown.Inline) bool) bool {
 		for _, inl := range inlines {
+			if !yield(inl) {
+				return false
+			}
 			switch ent := inl.(type) {
 			case *markdown.Strong:
-				if !walkLinks(ent.Inner, yield) {
+				if !walkInlines(ent.Inner, yield) {
 					return false
 				}
 			case *markdown.Emph:
-				if !walkLinks(ent.Inner, yield) {
+				if !walkInlines(ent.Inner, yield) {
+					return false
+				}
+			case *markdown.Del:
+				if !walkInlines(ent.Inner, yield) {
 					return false
 				}
 			case *markdown.Link:
-				if !yield(ent) {
+				if !walkInlines(ent.Inner, yield) {
 					return false
 				}
 			}
 		}
 		return true
 	}
-	var walkBlocks func(markdown.Block, func(*markdown.Link) bool) bool
-	walkBlocks = func(block markdown.Block, yield func(*markdown.Link) bool) bool {
+	var walkBlocks func(markdown.Block, func(markdown.Inline) bool) bool
+	walkBlocks = func(block markdown.Block, yield func(markdown.Inline) bool) bool {
 		switch bl := block.(type) {
 		case *markdown.Item:
 			for _, b := range bl.Blocks {
@@ -336,7 +1533,7 @@ func docLinks(doc *markdown.Document) iter.Seq[*markdown.Link] {
 				}
 			}
 		case *markdown.Paragraph:
-			if !walkLinks(bl.Text.Inline, yield) {
+			if !walkInlines(bl.Text.Inline, yield) {
 				return false
 			}
 		case *markdown.Quote:
@@ -346,14 +1543,31 @@ func docLinks(doc *markdown.Document) iter.Seq[*markdown.Link] {
 				}
 			}
 		case *markdown.Text:
-			if !walkLinks(bl.Inline, yield) {
+			if !walkInlines(bl.Inline, yield) {
+				return false
+			}
+		case *markdown.Heading:
+			if !walkInlines(bl.Text.Inline, yield) {
 				return false
 			}
+		case *markdown.Table:
+			for _, b := range bl.Header {
+				if !walkBlocks(b, yield) {
+					return false
+				}
+			}
+			for _, row := range bl.Rows {
+				for _, b := range row {
+					if !walkBlocks(b, yield) {
+						return false
+					}
+				}
+			}
 		}
 		return true
 	}
 
-	return func(yield func(*markdown.Link) bool) {
+	return func(yield func(markdown.Inline) bool) {
 		for _, b := range doc.Blocks {
 			if !walkBlocks(b, yield) {
 				return
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"rsc.io/markdown"
+)
+
+func TestHeadingSlugs(t *testing.T) {
+	for _, tc := range []struct {
+		src              string
+		regular, outline []string
+	}{
+		{"no headings\n", nil, nil},
+		{"# Hello, World\n", []string{"hello--world"}, []string{"h-hello-world"}},
+		{"# A\n\n## B\n\n# A\n\n### A\n\n# a\n",
+			[]string{"a", "b", "a-1", "a-2", "a-3"},
+			[]string{"h-a", "h-b", "h-a-1", "h-a-2", "h-a-3"}},
+		{"# 1.1 Usage\n\n> # Quoted\n", []string{"1-1-usage"}, []string{"h-11-usage"}},
+	} {
+		var p markdown.Parser
+		doc := p.Parse(tc.src)
+		if got := headingSlugs(doc, slugRegular); !slices.Equal(got, tc.regular) {
+			t.Errorf("regular slugs of %q = %q, want %q", tc.src, got, tc.regular)
+		}
+		if got := headingSlugs(doc, slugOutline); !slices.Equal(got, tc.outline) {
+			t.Errorf("outline slugs of %q = %q, want %q", tc.src, got, tc.outline)
+		}
+	}
+}
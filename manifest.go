@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// manifestName is the name of the file that maps local markdown files to
+// Outline documents; it is stored in the root of the directory tree.
+const manifestName = ".outline.json"
+
+type manifest struct {
+	Collection string `json:"collection,omitempty"`
+	// Documents are keyed by slash-separated paths relative to the
+	// manifest directory
+	Documents map[string]*manifestEntry `json:"documents"`
+	// Images are urls of local images uploaded as attachments, see
+	// uploadImages for the keys
+	Images map[string]string `json:"images,omitempty"`
+}
+
+type manifestEntry struct {
+	ID        string    `json:"id"`
+	UpdatedAt time.Time `json:"updatedAt,omitzero"` // remote document update time at the last sync
+	Hash      string    `json:"hash,omitempty"`     // local file contentHash at the last sync
+}
+
+// contentHash returns hex-encoded SHA-256 of data
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// imageCache returns the Images map for use as the uploadImages cache,
+// creating it if needed
+func (m *manifest) imageCache() map[string]string {
+	if m.Images == nil {
+		m.Images = make(map[string]string)
+	}
+	return m.Images
+}
+
+// readManifest reads manifest from the directory; if directory has no
+// manifest, an empty one is returned.
+func readManifest(dir string) (*manifest, error) {
+	m := &manifest{Documents: make(map[string]*manifestEntry)}
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	if m.Documents == nil {
+		m.Documents = make(map[string]*manifestEntry)
+	}
+	return m, nil
+}
+
+// save atomically writes manifest to the directory
+func (m *manifest) save(dir string) error {
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, manifestName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(dir, manifestName))
+}
+
+// findManifest looks for the manifest in the directory dir and its parents,
+// and returns the directory it is found in along with the manifest. If there
+// is no manifest, an empty one is returned with an empty directory name.
+func findManifest(dir string) (string, *manifest, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, manifestName)); err == nil {
+			m, err := readManifest(dir)
+			return dir, m, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", &manifest{Documents: make(map[string]*manifestEntry)}, nil
+		}
+		dir = parent
+	}
+}
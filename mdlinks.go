@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+// localDocs maps local markdown files to Outline documents, so links between
+// local files can be rewritten to links between documents and back. Files
+// are mapped by the manifest of the directory tree they are in, see
+// import-dir, and by ids in the front matter of files.
+type localDocs struct {
+	ids   map[string]string // absolute file name to document id or urlId
+	files map[string]string // document id or urlId to absolute file name, empty if there is none
+	urls  map[string]string // document id or urlId to its url
+}
+
+// loadLocalDocs maps files of the directory dir and files tracked by the
+// manifest of the directory tree dir belongs to
+func loadLocalDocs(dir string) (*localDocs, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	l := &localDocs{
+		ids:   make(map[string]string),
+		files: make(map[string]string),
+		urls:  make(map[string]string),
+	}
+	add := func(name, id string) {
+		l.ids[name], l.files[id] = id, name
+	}
+	root, m, err := findManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	for name, e := range m.Documents {
+		if e.Hash != "" { // skip directory placeholder documents
+			add(filepath.Join(root, filepath.FromSlash(name)), e.ID)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), ".md") {
+			continue
+		}
+		name := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		meta, _ := splitFrontMatter(data)
+		// files with malformed front matter are not ours to report
+		if fm, err := parseFrontMatter(meta); err == nil && fm.ID != "" {
+			add(name, documentID(fm.ID))
+		}
+	}
+	return l, nil
+}
+
+// offline makes toOutline link mapped documents by their ids, without
+// looking up their urls with the API. Outline resolves such links too.
+func (l *localDocs) offline() {
+	for _, id := range l.ids {
+		if _, ok := l.urls[id]; !ok {
+			l.urls[id] = "/doc/" + id
+		}
+	}
+}
+
+// toOutline rewrites links to the mapped local markdown files, relative to
+// the directory dir, into links to their documents. Links to headings are
+// rewritten to Outline style. It reports whether any link was changed.
+func (l *localDocs) toOutline(ctx context.Context, token authToken, doc *markdown.Document, dir string) (bool, error) {
+	if len(l.ids) == 0 {
+		return false, nil
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+	var changed bool
+	for link := range docLinks(doc) {
+		if !isLocalPath(link.URL) {
+			continue
+		}
+		p, heading, _ := strings.Cut(link.URL, "#")
+		if s, err := url.PathUnescape(p); err == nil {
+			p = s
+		}
+		if !strings.EqualFold(filepath.Ext(p), ".md") {
+			continue
+		}
+		name := filepath.Join(dir, filepath.FromSlash(p))
+		id, ok := l.ids[name]
+		if !ok {
+			continue
+		}
+		u, ok := l.urls[id]
+		if !ok {
+			d, err := documentInfo(ctx, token, id)
+			if err != nil {
+				return changed, err
+			}
+			u = d.URL
+			l.urls[id] = u
+		}
+		link.URL = u
+		if heading != "" {
+			link.URL += "#" + fileHeadingSlug(name, heading, slugRegular, slugOutline)
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// toLocal is the inverse of toOutline: it rewrites links to documents mapped
+// to local files into links to these files, relative to the directory dir.
+// Links to headings are rewritten to github style. It reports whether any
+// link was changed.
+func (l *localDocs) toLocal(ctx context.Context, token authToken, doc *markdown.Document, dir string) (bool, error) {
+	if len(l.files) == 0 {
+		return false, nil
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+	var changed bool
+	for link := range docLinks(doc) {
+		s, ok := strings.CutPrefix(strings.TrimPrefix(link.URL, webURL("")), "/doc/")
+		if !ok {
+			continue
+		}
+		s, heading, _ := strings.Cut(s, "#")
+		id := documentID(s)
+		name, ok := l.files[id]
+		if !ok {
+			// link has document urlId, while the file may be mapped by
+			// the full document id; links to documents that cannot be
+			// fetched are left as is
+			if d, err := documentInfo(ctx, token, id); err == nil {
+				name = l.files[d.ID]
+			}
+			l.files[id] = name
+		}
+		if name == "" {
+			continue
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			continue
+		}
+		link.URL = (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath()
+		if heading != "" {
+			link.URL += "#" + fileHeadingSlug(name, heading, slugOutline, slugRegular)
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// fileHeadingSlug translates slug of the markdown file heading generated by
+// the from function to the slug of the same heading generated by the to
+// function. If the file cannot be read or has no such heading, slug is
+// returned unchanged.
+func fileHeadingSlug(name, slug string, from, to func(string) string) string {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return slug
+	}
+	_, data = splitFrontMatter(data)
+	var p markdown.Parser
+	doc := p.Parse(string(data))
+	dst := headingSlugs(doc, to)
+	for i, s := range headingSlugs(doc, from) {
+		if s == slug {
+			return dst[i]
+		}
+	}
+	return slug
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+func handleMerge(ctx context.Context, token authToken, cliargs []string) error {
+	var into string
+	var archive, dryRun bool
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s merge -into url|urlid [flags] url|urlid...\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nDocuments are appended to the target one as sections titled after them, their headings are demoted one level.")
+	}
+	fs.StringVar(&into, "into", into, "target document url|urlid to merge documents into")
+	fs.BoolVar(&archive, "archive", archive, "replace merged documents text with a note linking to the target, and archive them")
+	fs.BoolVar(&dryRun, "dry-run", dryRun, "do not change anything, only print the merged text")
+	args := parseInterspersed(fs, cliargs)
+	if len(args) == 0 {
+		return errors.New("want at least one document url/urlid to merge")
+	}
+	if into == "" {
+		return errors.New("-into flag must be set")
+	}
+	target, err := documentInfo(ctx, token, documentID(into))
+	if err != nil {
+		return err
+	}
+	var p markdown.Parser
+	titles := make(map[string]struct{}) // section titles already used in the target
+	for _, b := range p.Parse(target.Text).Blocks {
+		if h, ok := b.(*markdown.Heading); ok {
+			titles[strings.ToLower(inlinesText(h.Text.Inline))] = struct{}{}
+		}
+	}
+	var sources []*document
+	merged := make(map[string]bool) // ids of documents already merged
+	var sb strings.Builder
+	sb.WriteString(strings.TrimRight(target.Text, "\n"))
+	for _, arg := range args {
+		d, err := documentInfo(ctx, token, documentID(arg))
+		if err != nil {
+			return err
+		}
+		if d.ID == target.ID {
+			return fmt.Errorf("document %q cannot be merged into itself", d.Title)
+		}
+		if merged[d.ID] {
+			continue // same document given more than once
+		}
+		merged[d.ID] = true
+		sources = append(sources, d)
+		doc := p.Parse(d.Text)
+		demoteHeadings(doc)
+		doc.Blocks = slices.Insert(doc.Blocks, 0, markdown.Block(&markdown.Heading{
+			Level: 1,
+			Text:  &markdown.Text{Inline: markdown.Inlines{&markdown.Plain{Text: escapeMarkdownText(uniqueName(titles, d.Title))}}},
+		}))
+		sb.WriteString("\n\n")
+		sb.WriteString(strings.TrimRight(markdown.Format(doc), "\n"))
+	}
+	sb.WriteString("\n")
+	if dryRun {
+		_, err := os.Stdout.WriteString(sb.String())
+		return err
+	}
+	if _, err := updateDocument(ctx, token, documentUpdate{Id: target.ID, Text: sb.String()}); err != nil {
+		return err
+	}
+	if !archive {
+		return nil
+	}
+	note := fmt.Sprintf("This document was merged into [%s](%s).\n", escapeLinkText(target.Title), target.URL)
+	for _, d := range sources {
+		if _, err := updateDocument(ctx, token, documentUpdate{Id: d.ID, Text: note}); err != nil {
+			return err
+		}
+		req := struct {
+			Id string `json:"id"`
+		}{Id: d.ID}
+		if err := apiCall(ctx, token, apiURL("documents.archive"), req); err != nil {
+			return fmt.Errorf("archiving %q: %w", d.Title, err)
+		}
+	}
+	return nil
+}
+
+// demoteHeadings increases level of all top-level document headings by one,
+// headings that are already at the deepest level are kept there
+func demoteHeadings(doc *markdown.Document) {
+	for _, b := range doc.Blocks {
+		if h, ok := b.(*markdown.Heading); ok && h.Level < 6 {
+			h.Level++
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+const nameTemplateHelp = `
+Name template is a Go text/template, see https://pkg.go.dev/text/template;
+it gets the following fields:
+
+  .ID, .UrlID     document ids
+  .Title          document title
+  .Collection     collection name
+  .Parents        titles of the parent documents, top-most first
+  .Path           default file path without extension: parent titles and the title
+  .UpdatedAt      time of the last document change
+
+Functions "slug" and "safe" make text suitable for use as a path element:
+"slug" turns it into lowercase words separated by dashes, "safe" only replaces
+unsafe characters. Slashes in the result separate directories; if result has
+no extension, ".md" is added. Example:
+
+  {{.Collection}}/{{.UpdatedAt.Format "2006"}}/{{slug .Title}}.md
+`
+
+// nameTemplate renders file names of documents saved to disk from
+// a user-provided template
+type nameTemplate struct {
+	t    *template.Template
+	seen map[string]struct{}
+}
+
+// nameTemplateData is what nameTemplate is executed with
+type nameTemplateData struct {
+	ID         string
+	UrlID      string
+	Title      string
+	Collection string
+	Parents    []string
+	Path       string
+	UpdatedAt  time.Time
+}
+
+func parseNameTemplate(text string) (*nameTemplate, error) {
+	t, err := template.New("name").Option("missingkey=error").Funcs(template.FuncMap{
+		"slug": fileSlug,
+		"safe": fileName,
+	}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &nameTemplate{t: t, seen: make(map[string]struct{})}, nil
+}
+
+// name returns a relative slash-separated file name for the document. Names
+// are unique across calls: on collision a numeric suffix is added.
+func (nt *nameTemplate) name(data nameTemplateData) (string, error) {
+	var sb strings.Builder
+	if err := nt.t.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	name := path.Clean(strings.TrimSpace(sb.String()))
+	if name == "." || !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", fmt.Errorf("name template produced invalid path %q for document %q", sb.String(), data.Title)
+	}
+	ext := path.Ext(name)
+	if ext == "" {
+		ext = ".md"
+		name += ext
+	}
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; ; i++ {
+		if _, ok := nt.seen[strings.ToLower(name)]; !ok {
+			break
+		}
+		name = base + " " + strconv.Itoa(i) + ext
+	}
+	nt.seen[strings.ToLower(name)] = struct{}{}
+	return name, nil
+}
+
+// fileSlug turns s into lowercase words of letters and digits separated by
+// single dashes
+func fileSlug(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "untitled"
+	}
+	return strings.Join(words, "-")
+}
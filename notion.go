@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+func handleImportNotion(ctx context.Context, token authToken, cliargs []string) error {
+	collection := defaultCollection
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s import-notion -collection id [flags] export.zip\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nCreates documents from the Notion \"Markdown & CSV\" export archive, recreating the page hierarchy:\n"+
+			"page foo/bar.md becomes a child of the page foo.md. Notion ids are removed from the titles, links\n"+
+			"between pages are rewritten to the new documents, and images and other files the pages refer to\n"+
+			"are uploaded as attachments.")
+	}
+	fs.StringVar(&collection, "collection", collection, "id or name of the collection to create documents in")
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != 1 {
+		return errors.New("want Notion export archive as the only positional argument")
+	}
+	if collection == "" {
+		return errors.New("-collection flag must be set")
+	}
+	var err error
+	if collection, err = collectionID(ctx, token, collection); err != nil {
+		return err
+	}
+	files, err := zipFiles(args[0])
+	if err != nil {
+		return err
+	}
+	var pages []string
+	for name := range files {
+		if strings.EqualFold(path.Ext(name), ".md") {
+			pages = append(pages, name)
+		}
+	}
+	if len(pages) == 0 {
+		return errors.New("no markdown pages found in the archive, make sure it is a Notion markdown export")
+	}
+	slices.Sort(pages)
+
+	// documents are created empty first, so the final text can link to any
+	// of them
+	created := make(map[string]*importedPage, len(pages))
+	var ensure func(name string) (*importedPage, error)
+	ensure = func(name string) (*importedPage, error) {
+		if p, ok := created[name]; ok {
+			return p, nil
+		}
+		data, err := files[name]()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		title, doc := parseDocument(data, transformOptions{})
+		if title == "" {
+			title = notionTitle(name)
+		}
+		// page foo/bar.md is a child of foo.md; if the latter is missing,
+		// of the nearest page up the tree
+		var parent string
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			if _, ok := files[dir+".md"]; ok {
+				p, err := ensure(dir + ".md")
+				if err != nil {
+					return nil, err
+				}
+				parent = p.d.ID
+				break
+			}
+		}
+		d, err := createDocument(ctx, token, newDocument{
+			Title:      title,
+			Collection: collection,
+			Parent:     parent,
+			Publish:    true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating document from %s: %w", name, err)
+		}
+		fmt.Printf("%s\t%s\t%s\n", name, d.ID, d.Title)
+		p := &importedPage{d: d, doc: doc}
+		created[name] = p
+		return p, nil
+	}
+	for _, name := range pages {
+		if _, err := ensure(name); err != nil {
+			return err
+		}
+	}
+	var uploaded int
+	for _, name := range pages {
+		p := created[name]
+		n, err := linkArchiveFiles(ctx, token, files, created, name, notionFileName)
+		uploaded += n
+		if err != nil {
+			return err
+		}
+		if _, err := updateDocument(ctx, token, documentUpdate{Id: p.d.ID, Text: markdown.Format(p.doc)}); err != nil {
+			return fmt.Errorf("updating document created from %s: %w", name, err)
+		}
+	}
+	fmt.Printf("\n%d documents created, %d files attached\n", len(created), uploaded)
+	return nil
+}
+
+// notionFileName returns base name of the Notion export file with the page id
+// Notion appends to names removed
+func notionFileName(name string) string {
+	ext := path.Ext(name)
+	return notionIDRe.ReplaceAllString(strings.TrimSuffix(path.Base(name), ext), "") + ext
+}
+
+// notionTitle returns page title derived from its Notion export file name
+func notionTitle(name string) string {
+	return strings.TrimSuffix(notionFileName(name), path.Ext(name))
+}
+
+var notionIDRe = regexp.MustCompile(`\s+[[:xdigit:]]{32}$`)
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+// numberHeadings prefixes document headings with hierarchical section numbers
+// like "1.", "1.1", "1.2.3", replacing any numbers already present. The
+// shallowest heading level found in the document is numbered as the top
+// level. Links to headings in Outline style are updated to match the new
+// heading text.
+func numberHeadings(doc *markdown.Document) {
+	minLevel := 0
+	for _, b := range doc.Blocks {
+		if h, ok := b.(*markdown.Heading); ok && (minLevel == 0 || h.Level < minLevel) {
+			minLevel = h.Level
+		}
+	}
+	if minLevel == 0 {
+		return
+	}
+	var counters [6]int
+	renumber := func(h *markdown.Heading) {
+		depth := h.Level - minLevel
+		counters[depth]++
+		clear(counters[depth+1:])
+		var parts []string
+		for _, n := range counters[:depth+1] {
+			parts = append(parts, strconv.Itoa(n))
+		}
+		prefix := strings.Join(parts, ".")
+		if depth == 0 {
+			prefix += "."
+		}
+		trimHeadingNumber(h)
+		h.Text.Inline = append(markdown.Inlines{&markdown.Plain{Text: prefix + " "}}, h.Text.Inline...)
+	}
+	rewriteHeadingsKeepLinks(doc, renumber)
+}
+
+// stripHeadingNumbers is the inverse of numberHeadings: it removes section
+// numbers from the headings and updates links to them.
+func stripHeadingNumbers(doc *markdown.Document) {
+	rewriteHeadingsKeepLinks(doc, trimHeadingNumber)
+}
+
+// rewriteHeadingsKeepLinks calls fn on each top-level heading of the document,
+// then fixes Outline-style links to headings whose text was changed by fn.
+func rewriteHeadingsKeepLinks(doc *markdown.Document, fn func(*markdown.Heading)) {
+	before := headingSlugs(doc, slugOutline)
+	for _, b := range doc.Blocks {
+		if h, ok := b.(*markdown.Heading); ok {
+			fn(h)
+		}
+	}
+	slugs := make(map[string]string) // old outline-style slug to the new one
+	for i, after := range headingSlugs(doc, slugOutline) {
+		if after != before[i] {
+			slugs["#"+before[i]] = "#" + after
+		}
+	}
+	replaceLinks(doc, slugs)
+}
+
+// headingNumberRe matches section numbers numberHeadings produces: "1." and
+// "1.2" or "1.2.", but not plain numbers starting headings like "2024 Roadmap"
+var headingNumberRe = regexp.MustCompile(`^(\d+\.(\d+\.)*|\d+(\.\d+)+)\s+`)
+
+// trimHeadingNumber removes section number prefix from the heading text
+func trimHeadingNumber(h *markdown.Heading) {
+	if len(h.Text.Inline) == 0 {
+		return
+	}
+	p, ok := h.Text.Inline[0].(*markdown.Plain)
+	if !ok {
+		return
+	}
+	p.Text = headingNumberRe.ReplaceAllLiteralString(p.Text, "")
+	if p.Text == "" {
+		h.Text.Inline = h.Text.Inline[1:]
+	}
+}
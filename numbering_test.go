@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"rsc.io/markdown"
+)
+
+func TestNumberHeadings(t *testing.T) {
+	for _, tc := range []struct {
+		src, numbered, stripped string
+	}{
+		{
+			src:      "## Intro\n\nSee [usage](#h-usage).\n\n### Usage\n\n### Details\n\n## 2024 Roadmap\n",
+			numbered: "## 1. Intro\n\nSee [usage](#h-11-usage).\n\n### 1.1 Usage\n\n### 1.2 Details\n\n## 2. 2024 Roadmap\n",
+			stripped: "## Intro\n\nSee [usage](#h-usage).\n\n### Usage\n\n### Details\n\n## 2024 Roadmap\n",
+		},
+		{
+			// existing numbers are replaced
+			src:      "# 3. Old\n\n## 3.1 Old sub\n\n# Next\n",
+			numbered: "# 1. Old\n\n## 1.1 Old sub\n\n# 2. Next\n",
+			stripped: "# Old\n\n## Old sub\n\n# Next\n",
+		},
+		{
+			src:      "# 3 Ways to win\n\n## 1.2.3 Deep\n",
+			numbered: "# 1. 3 Ways to win\n\n## 1.1 Deep\n",
+			stripped: "# 3 Ways to win\n\n## Deep\n",
+		},
+		{
+			src:      "no headings\n",
+			numbered: "no headings\n",
+			stripped: "no headings\n",
+		},
+	} {
+		var p markdown.Parser
+		doc := p.Parse(tc.src)
+		numberHeadings(doc)
+		if got := markdown.Format(doc); got != tc.numbered {
+			t.Errorf("numberHeadings(%q) = %q, want %q", tc.src, got, tc.numbered)
+			continue
+		}
+		doc = p.Parse(tc.numbered)
+		stripHeadingNumbers(doc)
+		if got := markdown.Format(doc); got != tc.stripped {
+			t.Errorf("stripHeadingNumbers(%q) = %q, want %q", tc.numbered, got, tc.stripped)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+func handleImportObsidian(ctx context.Context, token authToken, cliargs []string) error {
+	collection := defaultCollection
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s import-obsidian -collection id [flags] vault-directory\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nCreates documents from the Obsidian vault notes, nested the same way import-dir does it: note\n"+
+			"foo/bar.md becomes a child of the note foo.md if it exists, otherwise an empty document is created\n"+
+			"for the folder foo. Notes are titled after their file names, unless they start with H1 heading.\n"+
+			"Wikilinks like [[Note]] or [[Note#Heading|text]] are converted to links to the new documents,\n"+
+			"embeds like ![[image.png]] and other files notes refer to are uploaded as attachments.")
+	}
+	fs.StringVar(&collection, "collection", collection, "id or name of the collection to create documents in")
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != 1 {
+		return errors.New("want vault directory as the only positional argument")
+	}
+	if collection == "" {
+		return errors.New("-collection flag must be set")
+	}
+	var err error
+	if collection, err = collectionID(ctx, token, collection); err != nil {
+		return err
+	}
+	files, err := dirFiles(args[0])
+	if err != nil {
+		return err
+	}
+	var notes []string
+	byPath := make(map[string]string) // lowercase path to file name
+	byBase := make(map[string]string) // lowercase base name to the file name with the shortest path
+	for name := range files {
+		if strings.EqualFold(path.Ext(name), ".md") {
+			notes = append(notes, name)
+		}
+		byPath[strings.ToLower(name)] = name
+		base := strings.ToLower(path.Base(name))
+		if s, ok := byBase[base]; !ok || len(name) < len(s) || len(name) == len(s) && name < s {
+			byBase[base] = name
+		}
+	}
+	if len(notes) == 0 {
+		return errors.New("no notes found in the vault")
+	}
+	slices.Sort(notes)
+	// resolve returns file name the wikilink target refers to, Obsidian
+	// matches targets by the file path or the file name, with .md extension
+	// of notes omitted
+	resolve := func(target string) string {
+		key := strings.ToLower(strings.TrimPrefix(target, "/"))
+		for _, k := range []string{key + ".md", key} {
+			if s, ok := byPath[k]; ok {
+				return s
+			}
+			if s, ok := byBase[k]; ok {
+				return s
+			}
+		}
+		return ""
+	}
+
+	// documents are created empty first, so the final text can link to any
+	// of them
+	created := make(map[string]*importedPage, len(notes))
+	dirDocs := make(map[string]string) // folder name to document id
+	var ensureDir func(dir string) (string, error)
+	create := func(name, dir, title string) (*document, error) {
+		parent, err := ensureDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		d, err := createDocument(ctx, token, newDocument{
+			Title:      title,
+			Collection: collection,
+			Parent:     parent,
+			Publish:    true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating document from %s: %w", name, err)
+		}
+		fmt.Printf("%s\t%s\t%s\n", name, d.ID, d.Title)
+		return d, nil
+	}
+	ensureNote := func(name string) (*importedPage, error) {
+		if p, ok := created[name]; ok {
+			return p, nil
+		}
+		data, err := files[name]()
+		if err != nil {
+			return nil, err
+		}
+		title, doc := parseDocument(data, transformOptions{keepH1: true})
+		if h, ok := firstBlock(doc).(*markdown.Heading); ok && h.Level == 1 {
+			dropLeadingH1(doc)
+		} else {
+			title = fileTitle(name)
+		}
+		d, err := create(name, path.Dir(name), title)
+		if err != nil {
+			return nil, err
+		}
+		p := &importedPage{d: d, doc: doc}
+		created[name] = p
+		return p, nil
+	}
+	ensureDir = func(dir string) (string, error) {
+		if dir == "." {
+			return "", nil
+		}
+		if _, ok := files[dir+".md"]; ok {
+			p, err := ensureNote(dir + ".md")
+			if err != nil {
+				return "", err
+			}
+			return p.d.ID, nil
+		}
+		if id, ok := dirDocs[dir]; ok {
+			return id, nil
+		}
+		d, err := create(dir, path.Dir(dir), path.Base(dir))
+		if err != nil {
+			return "", err
+		}
+		dirDocs[dir] = d.ID
+		return d.ID, nil
+	}
+	for _, name := range notes {
+		if _, err := ensureNote(name); err != nil {
+			return err
+		}
+	}
+	var uploaded, unresolved int
+	for _, name := range notes {
+		p := created[name]
+		replaceWikiLinks(p.doc, func(w wikiLink) markdown.Inline {
+			if w.target == "" {
+				if w.heading == "" {
+					return nil
+				}
+				w.embed = false
+				return w.inline("#" + slugOutline(w.heading))
+			}
+			file := resolve(w.target)
+			if file == "" {
+				unresolved++
+				fmt.Fprintf(os.Stderr, "%s: cannot resolve link to %q\n", name, w.target)
+				return nil
+			}
+			link := relativeLink(name, file)
+			if _, ok := created[file]; ok {
+				w.embed = false // embedded notes become links
+				if w.heading != "" {
+					link += "#" + slugOutline(w.heading)
+				}
+			}
+			return w.inline(link)
+		})
+		n, err := linkArchiveFiles(ctx, token, files, created, name, path.Base)
+		uploaded += n
+		if err != nil {
+			return err
+		}
+		if _, err := updateDocument(ctx, token, documentUpdate{Id: p.d.ID, Text: markdown.Format(p.doc)}); err != nil {
+			return fmt.Errorf("updating document created from %s: %w", name, err)
+		}
+	}
+	fmt.Printf("\n%d documents created, %d files attached, %d links not resolved\n", len(created)+len(dirDocs), uploaded, unresolved)
+	return nil
+}
+
+// relativeLink returns url-escaped link from the file from to the file to,
+// both being slash-separated paths relative to the same directory
+func relativeLink(from, to string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(to))
+	if err != nil {
+		rel = to
+	}
+	return (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath()
+}
+
+// firstBlock returns the first block of the document, or nil if the document
+// is empty
+func firstBlock(doc *markdown.Document) markdown.Block {
+	if len(doc.Blocks) == 0 {
+		return nil
+	}
+	return doc.Blocks[0]
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+func handleOpen(ctx context.Context, token authToken, cliargs []string) error {
+	var printOnly bool
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s open [flags] url|urlid|file.md\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nOpens the document in the default web browser. Local markdown file must have document id in its front matter.")
+	}
+	fs.BoolVar(&printOnly, "print", printOnly, "only print the document url, do not open it")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url, id, or file")
+	}
+	if len(args) == 1 && strings.HasSuffix(strings.ToLower(args[0]), ".md") {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		meta, _ := splitFrontMatter(data)
+		fm, err := parseFrontMatter(meta)
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+		if fm.ID == "" {
+			return fmt.Errorf("%s has no document id in its front matter", args[0])
+		}
+		args[0] = fm.ID
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	d, err := documentInfo(ctx, token, urlid)
+	if err != nil {
+		return err
+	}
+	link := webURL(d.URL)
+	if printOnly {
+		fmt.Println(link)
+		return nil
+	}
+	return openBrowser(link)
+}
+
+// openBrowser opens link in the default web browser
+func openBrowser(link string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", link)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		cmd = exec.Command("xdg-open", link)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("opening browser: %w", err)
+	}
+	return cmd.Wait()
+}
@@ -0,0 +1,656 @@
+// Package outlinetest provides an in-memory fake of the Outline API for
+// running the client offline.
+//
+// The fake implements the subset of documents.*, collections.*, and
+// attachments.* methods the outline command uses. Point the command at it
+// with the OUTLINE_BASE_URL environment variable set to the server URL:
+//
+//	srv := outlinetest.NewServer()
+//	defer srv.Close()
+//	c := srv.AddCollection("Docs")
+//	srv.AddDocument(c.ID, "", "Welcome", "Hello, world.")
+//	os.Setenv("OUTLINE_BASE_URL", srv.URL)
+//	os.Setenv("OUTLINE_TOKEN", srv.Token)
+//
+// Errors are reported the way Outline does it, with the JSON body in the
+// {"ok":false,"error":...,"message":...} form.
+//
+// The client is package main, so the fake is for the tests of this module
+// and for running the command offline; there is no client library for it to
+// be used with elsewhere.
+package outlinetest
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a fake Outline instance serving the API from memory. Its methods
+// are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+	// Token is the API token requests must be authorized with. If empty,
+	// requests are not checked.
+	Token string
+
+	mu          sync.Mutex
+	seq         int
+	documents   []*Document // in the creation order
+	collections []*Collection
+	attachments []*Attachment
+	files       map[string][]byte // attachment id to uploaded contents
+}
+
+// Document is a document stored by the Server
+type Document struct {
+	ID           string     `json:"id"`
+	UrlID        string     `json:"urlId"`
+	URL          string     `json:"url"`
+	Title        string     `json:"title"`
+	Text         string     `json:"text"`
+	CollectionID string     `json:"collectionId"`
+	ParentID     string     `json:"parentDocumentId,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	PublishedAt  *time.Time `json:"publishedAt"`
+	ArchivedAt   *time.Time `json:"archivedAt"`
+	DeletedAt    *time.Time `json:"deletedAt"`
+	Template     bool       `json:"template"`
+	Revision     int        `json:"revision"`
+	Icon         string     `json:"icon,omitempty"`
+	FullWidth    bool       `json:"fullWidth"`
+}
+
+// Collection is a collection stored by the Server
+type Collection struct {
+	ID          string `json:"id"`
+	UrlID       string `json:"urlId"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color,omitempty"`
+	Icon        string `json:"icon,omitempty"`
+	Permission  string `json:"permission,omitempty"`
+}
+
+// Attachment is a file attached to a document
+type Attachment struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url"`
+	DocumentID  string    `json:"documentId,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// NewServer starts and returns a new Server with no data and a random
+// token. The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{files: make(map[string][]byte)}
+	s.Token = "ol_api_" + strings.ReplaceAll(s.newID(), "-", "")
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddCollection creates a new collection
+func (s *Server) AddCollection(name string) *Collection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addCollection(name, "")
+}
+
+// AddDocument creates a new published document. If parentID is set, the
+// document is nested under that document.
+func (s *Server) AddDocument(collectionID, parentID, title, text string) *Document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addDocument(collectionID, parentID, title, text, true)
+}
+
+// Document returns a copy of the document with the id or urlId
+func (s *Server) Document(id string) (Document, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d := s.document(id); d != nil {
+		return *d, true
+	}
+	return Document{}, false
+}
+
+// Documents returns copies of all documents, including deleted ones, in the
+// order they were created
+func (s *Server) Documents() []Document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Document, len(s.documents))
+	for i, d := range s.documents {
+		out[i] = *d
+	}
+	return out
+}
+
+// AttachmentData returns the contents uploaded for the attachment with the id
+func (s *Server) AttachmentData(id string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.files[id]
+	return b, ok
+}
+
+func (s *Server) newID() string {
+	s.seq++
+	return fmt.Sprintf("%08x-0000-4000-8000-%012x", time.Now().Unix()&0xffffffff, s.seq)
+}
+
+// newUrlID returns 10 characters long url id, the format Outline uses
+func (s *Server) newUrlID() string {
+	return fmt.Sprintf("t%09d", s.seq)
+}
+
+func (s *Server) addCollection(name, description string) *Collection {
+	c := &Collection{ID: s.newID(), Name: name, Description: description, Permission: "read_write"}
+	c.UrlID = s.newUrlID()
+	s.collections = append(s.collections, c)
+	return c
+}
+
+func (s *Server) addDocument(collectionID, parentID, title, text string, publish bool) *Document {
+	now := time.Now().UTC()
+	d := &Document{
+		ID:           s.newID(),
+		Title:        title,
+		Text:         text,
+		CollectionID: collectionID,
+		ParentID:     parentID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Revision:     1,
+	}
+	d.UrlID = s.newUrlID()
+	d.URL = "/doc/" + slug(title) + "-" + d.UrlID
+	if publish {
+		d.PublishedAt = &now
+	}
+	s.documents = append(s.documents, d)
+	return d
+}
+
+func (s *Server) document(id string) *Document {
+	for _, d := range s.documents {
+		if d.ID == id || d.UrlID == id || strings.HasSuffix(id, "-"+d.UrlID) {
+			return d
+		}
+	}
+	return nil
+}
+
+func (s *Server) collection(id string) *Collection {
+	for _, c := range s.collections {
+		if c.ID == id || c.UrlID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// apiError is an error response in the Outline format
+type apiError struct {
+	status  int
+	name    string
+	message string
+}
+
+func (e *apiError) Error() string { return e.message }
+
+func notFound(what string) error {
+	return &apiError{status: http.StatusNotFound, name: "not_found", message: what + " not found"}
+}
+
+func badRequest(message string) error {
+	return &apiError{status: http.StatusBadRequest, name: "validation_error", message: message}
+}
+
+// request holds attributes of all requests the Server understands
+type request struct {
+	ID          string  `json:"id"`
+	Collection  string  `json:"collectionId"`
+	Parent      string  `json:"parentDocumentId"`
+	Backlink    string  `json:"backlinkDocumentId"`
+	Document    string  `json:"documentId"`
+	Title       string  `json:"title"`
+	Text        *string `json:"text"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Query       string  `json:"query"`
+	Publish     bool    `json:"publish"`
+	Template    bool    `json:"template"`
+	Append      bool    `json:"append"`
+	Permanent   bool    `json:"permanent"`
+	Icon        *string `json:"icon"`
+	FullWidth   *bool   `json:"fullWidth"`
+	ContentType string  `json:"contentType"`
+	Size        int64   `json:"size"`
+	Limit       int     `json:"limit"`
+	Offset      int     `json:"offset"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/attachments.redirect" {
+		s.serveAttachment(w, r)
+		return
+	}
+	method, ok := strings.CutPrefix(r.URL.Path, "/api/")
+	if !ok || r.Method != http.MethodPost {
+		writeError(w, &apiError{status: http.StatusNotFound, name: "not_found", message: "Resource not found"})
+		return
+	}
+	if s.Token != "" && r.Header.Get("Authorization") != "Bearer "+s.Token {
+		writeError(w, &apiError{status: http.StatusUnauthorized, name: "authentication_required", message: "Authentication required"})
+		return
+	}
+	if method == "files.create" {
+		s.serveUpload(w, r)
+		return
+	}
+	var req request
+	if err := json.NewDecoder(io.LimitReader(r.Body, 10<<20)).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, badRequest("invalid JSON body: "+err.Error()))
+		return
+	}
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 25
+	}
+	s.mu.Lock()
+	data, err := s.call(method, &req)
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(struct {
+		Data   any  `json:"data"`
+		Status int  `json:"status"`
+		OK     bool `json:"ok"`
+	}{Data: data, Status: http.StatusOK, OK: true})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	e, ok := err.(*apiError)
+	if !ok {
+		e = &apiError{status: http.StatusInternalServerError, name: "internal_error", message: err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(e.status)
+	json.NewEncoder(w).Encode(struct {
+		OK      bool   `json:"ok"`
+		Error   string `json:"error"`
+		Message string `json:"message"`
+		Status  int    `json:"status"`
+	}{Error: e.name, Message: e.message, Status: e.status})
+}
+
+// call handles the API method, it must be called with s.mu held
+func (s *Server) call(method string, req *request) (any, error) {
+	now := time.Now().UTC()
+	switch method {
+	case "documents.info":
+		d := s.document(req.ID)
+		if d == nil || d.DeletedAt != nil {
+			return nil, notFound("Document")
+		}
+		return d, nil
+	case "documents.list", "documents.drafts", "documents.deleted", "documents.archived":
+		var out []*Document
+		for _, d := range s.documents {
+			switch {
+			case (d.DeletedAt != nil) != (method == "documents.deleted"),
+				(d.ArchivedAt != nil) != (method == "documents.archived"),
+				method == "documents.drafts" && d.PublishedAt != nil,
+				method == "documents.list" && d.PublishedAt == nil,
+				req.Collection != "" && d.CollectionID != req.Collection,
+				req.Parent != "" && d.ParentID != req.Parent,
+				req.Backlink != "" && !s.linksTo(d, req.Backlink):
+				continue
+			}
+			out = append(out, d)
+		}
+		if method == "documents.list" {
+			// the client asks for the most recently updated first
+			slices.SortStableFunc(out, func(a, b *Document) int { return b.UpdatedAt.Compare(a.UpdatedAt) })
+		}
+		return page(out, req), nil
+	case "documents.search":
+		type result struct {
+			Context  string    `json:"context"`
+			Document *Document `json:"document"`
+		}
+		var out []result
+		q := strings.ToLower(strings.TrimSpace(req.Query))
+		for _, d := range s.documents {
+			if d.DeletedAt != nil || d.ArchivedAt != nil || d.PublishedAt == nil ||
+				req.Collection != "" && d.CollectionID != req.Collection {
+				continue
+			}
+			i := strings.Index(strings.ToLower(d.Text), q)
+			if i < 0 && !strings.Contains(strings.ToLower(d.Title), q) {
+				continue
+			}
+			var context string
+			if i >= 0 {
+				context = d.Text[max(0, i-40):min(len(d.Text), i+len(q)+40)]
+			}
+			out = append(out, result{Context: context, Document: d})
+		}
+		return page(out, req), nil
+	case "documents.create":
+		if req.Collection != "" && s.collection(req.Collection) == nil {
+			return nil, notFound("Collection")
+		}
+		if req.Parent != "" {
+			p := s.document(req.Parent)
+			if p == nil {
+				return nil, notFound("Parent document")
+			}
+			req.Collection = p.CollectionID
+		}
+		if req.Collection == "" && req.Publish {
+			return nil, badRequest("collectionId is required to publish")
+		}
+		var text string
+		if req.Text != nil {
+			text = *req.Text
+		}
+		d := s.addDocument(req.Collection, req.Parent, req.Title, text, req.Publish)
+		d.Template = req.Template
+		if req.Icon != nil {
+			d.Icon = *req.Icon
+		}
+		if req.FullWidth != nil {
+			d.FullWidth = *req.FullWidth
+		}
+		return d, nil
+	case "documents.update":
+		d := s.document(req.ID)
+		if d == nil || d.DeletedAt != nil {
+			return nil, notFound("Document")
+		}
+		if req.Title != "" {
+			d.Title = req.Title
+			d.URL = "/doc/" + slug(d.Title) + "-" + d.UrlID
+		}
+		if req.Text != nil {
+			if req.Append {
+				d.Text += *req.Text
+			} else {
+				d.Text = *req.Text
+			}
+		}
+		if req.Icon != nil {
+			d.Icon = *req.Icon
+		}
+		if req.FullWidth != nil {
+			d.FullWidth = *req.FullWidth
+		}
+		if req.Publish && d.PublishedAt == nil {
+			if d.CollectionID == "" {
+				return nil, badRequest("collectionId is required to publish")
+			}
+			d.PublishedAt = &now
+		}
+		d.UpdatedAt = now
+		d.Revision++
+		return d, nil
+	case "documents.move":
+		d := s.document(req.ID)
+		if d == nil || d.DeletedAt != nil {
+			return nil, notFound("Document")
+		}
+		if req.Collection != "" && s.collection(req.Collection) == nil {
+			return nil, notFound("Collection")
+		}
+		if req.Parent != "" {
+			p := s.document(req.Parent)
+			if p == nil {
+				return nil, notFound("Parent document")
+			}
+			req.Collection = p.CollectionID
+		}
+		d.CollectionID, d.ParentID = cmp.Or(req.Collection, d.CollectionID), req.Parent
+		d.UpdatedAt = now
+		return struct {
+			Documents []*Document `json:"documents"`
+		}{Documents: []*Document{d}}, nil
+	case "documents.archive":
+		d := s.document(req.ID)
+		if d == nil || d.DeletedAt != nil {
+			return nil, notFound("Document")
+		}
+		d.ArchivedAt, d.UpdatedAt = &now, now
+		return d, nil
+	case "documents.unpublish":
+		d := s.document(req.ID)
+		if d == nil || d.DeletedAt != nil {
+			return nil, notFound("Document")
+		}
+		d.PublishedAt, d.UpdatedAt = nil, now
+		return d, nil
+	case "documents.restore":
+		d := s.document(req.ID)
+		if d == nil {
+			return nil, notFound("Document")
+		}
+		d.ArchivedAt, d.DeletedAt, d.UpdatedAt = nil, nil, now
+		if req.Collection != "" {
+			d.CollectionID = req.Collection
+		}
+		return d, nil
+	case "documents.delete":
+		d := s.document(req.ID)
+		if d == nil {
+			return nil, notFound("Document")
+		}
+		if req.Permanent {
+			s.documents = slices.DeleteFunc(s.documents, func(x *Document) bool { return x == d })
+		} else {
+			d.DeletedAt = &now
+		}
+		return true, nil
+	case "documents.empty_trash":
+		s.documents = slices.DeleteFunc(s.documents, func(x *Document) bool { return x.DeletedAt != nil })
+		return true, nil
+	case "collections.list":
+		return page(s.collections, req), nil
+	case "collections.info":
+		c := s.collection(req.ID)
+		if c == nil {
+			return nil, notFound("Collection")
+		}
+		return c, nil
+	case "collections.create":
+		if req.Name == "" {
+			return nil, badRequest("name is required")
+		}
+		return s.addCollection(req.Name, req.Description), nil
+	case "collections.update":
+		c := s.collection(req.ID)
+		if c == nil {
+			return nil, notFound("Collection")
+		}
+		c.Name = cmp.Or(req.Name, c.Name)
+		c.Description = cmp.Or(req.Description, c.Description)
+		return c, nil
+	case "collections.delete":
+		c := s.collection(req.ID)
+		if c == nil {
+			return nil, notFound("Collection")
+		}
+		s.collections = slices.DeleteFunc(s.collections, func(x *Collection) bool { return x == c })
+		s.documents = slices.DeleteFunc(s.documents, func(x *Document) bool { return x.CollectionID == c.ID })
+		return true, nil
+	case "collections.documents":
+		c := s.collection(req.ID)
+		if c == nil {
+			return nil, notFound("Collection")
+		}
+		return s.navTree(c.ID, ""), nil
+	case "attachments.create":
+		if req.Name == "" {
+			return nil, badRequest("name is required")
+		}
+		if req.Document != "" && s.document(req.Document) == nil {
+			return nil, notFound("Document")
+		}
+		a := &Attachment{
+			ID:          s.newID(),
+			Name:        req.Name,
+			ContentType: req.ContentType,
+			Size:        req.Size,
+			DocumentID:  req.Document,
+			CreatedAt:   now,
+		}
+		a.URL = "/api/attachments.redirect?id=" + a.ID
+		s.attachments = append(s.attachments, a)
+		return struct {
+			UploadURL  string            `json:"uploadUrl"`
+			Form       map[string]string `json:"form"`
+			Attachment *Attachment       `json:"attachment"`
+		}{
+			UploadURL:  "/api/files.create",
+			Form:       map[string]string{"key": a.ID},
+			Attachment: a,
+		}, nil
+	case "attachments.list":
+		var out []*Attachment
+		for _, a := range s.attachments {
+			if req.Document == "" || a.DocumentID == req.Document {
+				out = append(out, a)
+			}
+		}
+		return page(out, req), nil
+	case "attachments.delete":
+		n := len(s.attachments)
+		s.attachments = slices.DeleteFunc(s.attachments, func(a *Attachment) bool { return a.ID == req.ID })
+		if len(s.attachments) == n {
+			return nil, notFound("Attachment")
+		}
+		delete(s.files, req.ID)
+		return true, nil
+	}
+	return nil, &apiError{status: http.StatusNotFound, name: "not_found", message: "Resource not found"}
+}
+
+// serveUpload stores file uploaded for the attachment created by the
+// attachments.create method
+func (s *Server) serveUpload(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 100<<20)
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		writeError(w, badRequest(err.Error()))
+		return
+	}
+	f, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, badRequest(err.Error()))
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		writeError(w, badRequest(err.Error()))
+		return
+	}
+	id := r.FormValue("key")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !slices.ContainsFunc(s.attachments, func(a *Attachment) bool { return a.ID == id }) {
+		writeError(w, notFound("Attachment"))
+		return
+	}
+	s.files[id] = data
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) serveAttachment(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	data, ok := s.files[r.FormValue("id")]
+	var contentType string
+	for _, a := range s.attachments {
+		if a.ID == r.FormValue("id") {
+			contentType = a.ContentType
+		}
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Write(data)
+}
+
+// navNode is an element of the collection document structure
+type navNode struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	URL      string    `json:"url"`
+	Children []navNode `json:"children"`
+}
+
+func (s *Server) navTree(collectionID, parentID string) []navNode {
+	out := []navNode{}
+	for _, d := range s.documents {
+		if d.CollectionID != collectionID || d.ParentID != parentID ||
+			d.PublishedAt == nil || d.DeletedAt != nil || d.ArchivedAt != nil {
+			continue
+		}
+		out = append(out, navNode{ID: d.ID, Title: d.Title, URL: d.URL, Children: s.navTree(collectionID, d.ID)})
+	}
+	return out
+}
+
+// linksTo reports whether the document d links to the document with the id
+func (s *Server) linksTo(d *Document, id string) bool {
+	t := s.document(id)
+	return t != nil && t != d && strings.Contains(d.Text, "/doc/") &&
+		(strings.Contains(d.Text, t.URL) || strings.Contains(d.Text, "-"+t.UrlID+")") || strings.Contains(d.Text, "/doc/"+t.UrlID))
+}
+
+// page returns the page of items the request asks for
+func page[T any](items []T, req *request) []T {
+	if req.Offset >= len(items) {
+		return []T{}
+	}
+	return items[req.Offset:min(len(items), req.Offset+req.Limit)]
+}
+
+// slug returns url-friendly version of the title, similar to Outline one
+func slug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if dash && b.Len() != 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "untitled"
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// jsonOutput is set by the global -json flag: subcommands that support it
+// print structured JSON instead of human-readable text
+var jsonOutput bool
+
+// docSummary is document representation used in JSON output
+type docSummary struct {
+	ID        string     `json:"id"`
+	UrlID     string     `json:"urlId"`
+	Title     string     `json:"title"`
+	URL       string     `json:"url"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	Context   string     `json:"context,omitempty"` // search result context
+	Text      string     `json:"text,omitempty"`
+	Comments  []*comment `json:"comments,omitempty"`
+}
+
+func summarize(d *document) docSummary {
+	return docSummary{
+		ID:        d.ID,
+		UrlID:     d.UrlID,
+		Title:     d.Title,
+		URL:       webURL(d.URL),
+		UpdatedAt: d.UpdatedAt,
+	}
+}
+
+// writeJSON is like writeResult, but saves v encoded as JSON
+func writeJSON(dstFile string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeResult(dstFile, append(data, '\n'))
+}
+
+// writeDocuments saves a list of documents to dstFile, one per line with
+// tab-separated id, last update time, and title; or as a JSON array in the
+// JSON output mode.
+func writeDocuments(dstFile string, docs iter.Seq2[document, error]) error {
+	var buf bytes.Buffer
+	out := []docSummary{}
+	for d, err := range docs {
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			out = append(out, summarize(&d))
+			continue
+		}
+		fmt.Fprintf(&buf, "%s\t%s\t%s\n", d.ID, d.UpdatedAt.Format(time.RFC3339), d.Title)
+	}
+	if jsonOutput {
+		return writeJSON(dstFile, out)
+	}
+	return writeResult(dstFile, buf.Bytes())
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+)
+
+// defaultPDFCommand is used to convert documents to PDF if neither -pdf-command
+// flag nor OUTLINE_PDF_COMMAND environment variable is set
+const defaultPDFCommand = "wkhtmltopdf --quiet --encoding utf-8 --enable-local-file-access - -"
+
+// renderPDF converts HTML body of the document to PDF by running command with
+// the shell in the directory dir. The command reads a complete HTML page from
+// stdin and must write PDF to stdout.
+func renderPDF(ctx context.Context, command, title, body, dir string) ([]byte, error) {
+	var page strings.Builder
+	page.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&page, "<title>%s</title>\n</head>\n<body>\n", html.EscapeString(title))
+	page.WriteString(body)
+	page.WriteString("</body>\n</html>\n")
+	var stdout bytes.Buffer
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(page.String())
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("running pdf command: %w", err)
+	}
+	if !bytes.HasPrefix(stdout.Bytes(), []byte("%PDF-")) {
+		return nil, errors.New("pdf command did not write PDF to stdout")
+	}
+	return stdout.Bytes(), nil
+}
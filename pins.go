@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"iter"
+)
+
+func handlePin(ctx context.Context, token authToken, cliargs []string) error {
+	return pinCmd(ctx, token, "pin", cliargs)
+}
+
+func handleUnpin(ctx context.Context, token authToken, cliargs []string) error {
+	return pinCmd(ctx, token, "unpin", cliargs)
+}
+
+// pinCmd implements pin and unpin subcommands
+func pinCmd(ctx context.Context, token authToken, name string, cliargs []string) error {
+	var collection string
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [-collection id] url|urlid\n", exeName, name)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&collection, "collection", collection, "collection id or name to pin document in, if not set, document is pinned to the home page")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url or id")
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	d, err := documentInfo(ctx, token, urlid)
+	if err != nil {
+		return err
+	}
+	if collection, err = collectionID(ctx, token, collection); err != nil {
+		return err
+	}
+	if name == "pin" {
+		req := struct {
+			Document   string `json:"documentId"`
+			Collection string `json:"collectionId,omitempty"`
+		}{Document: d.ID, Collection: collection}
+		return apiCall(ctx, token, apiURL("pins.create"), req)
+	}
+	for p, err := range listPins(ctx, token, collection) {
+		if err != nil {
+			return err
+		}
+		if p.DocumentID != d.ID || p.CollectionID != collection {
+			continue
+		}
+		req := struct {
+			Id string `json:"id"`
+		}{Id: p.ID}
+		return apiCall(ctx, token, apiURL("pins.delete"), req)
+	}
+	if collection != "" {
+		return fmt.Errorf("document %q is not pinned in the collection", d.Title)
+	}
+	return fmt.Errorf("document %q is not pinned to the home page", d.Title)
+}
+
+// pin is a document pinned to the home page or to a collection
+type pin struct {
+	ID           string `json:"id"`
+	DocumentID   string `json:"documentId"`
+	CollectionID string `json:"collectionId"` // empty for home page pins
+}
+
+// listPins iterates over pins of the collection, or of the home page if
+// collection is empty
+func listPins(ctx context.Context, token authToken, collection string) iter.Seq2[pin, error] {
+	const pageSize = 100
+	return func(yield func(pin, error) bool) {
+		for offset := 0; ; offset += pageSize {
+			req := struct {
+				pagination
+				Collection string `json:"collectionId,omitempty"`
+			}{pagination: pagination{Limit: pageSize, Offset: offset}, Collection: collection}
+			res, err := apiRequest[struct {
+				Pins []pin `json:"pins"`
+			}](ctx, token, apiURL("pins.list"), req)
+			if err != nil {
+				yield(pin{}, err)
+				return
+			}
+			for _, p := range res.Pins {
+				if !yield(p, nil) {
+					return
+				}
+			}
+			if len(res.Pins) < pageSize {
+				return
+			}
+		}
+	}
+}
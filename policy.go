@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func handleEnforcePolicy(ctx context.Context, token authToken, cliargs []string) error {
+	var dstFile string
+	var dryRun bool
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s enforce-policy [flags] policy.yaml\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), policyHelp)
+	}
+	fs.StringVar(&dstFile, "o", dstFile, "file to save report to, if not set, it will be printed to stdout")
+	fs.BoolVar(&dryRun, "dry-run", dryRun, "only report documents matching the rules, do not act on them")
+	fs.Parse(cliargs)
+	if fs.NArg() == 0 {
+		return errors.New("want policy file as the first positional argument")
+	}
+	pol, err := readPolicy(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tACTION\tUPDATED\tDOCUMENT\tRESULT")
+	now := time.Now()
+	var failed int
+	for _, rule := range pol.Rules {
+		collection, err := collectionID(ctx, token, rule.Collection)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rule.Name, err)
+		}
+		// documents are collected before acting on them, as archived
+		// documents drop out of the list, shifting the pages
+		var matched []document
+		for d, err := range listDocuments(ctx, token, collection, 0) {
+			if err != nil {
+				return err
+			}
+			if now.Sub(d.UpdatedAt) >= rule.age {
+				matched = append(matched, d)
+			}
+		}
+		for _, d := range matched {
+			result := "dry run"
+			if !dryRun {
+				result = "done"
+				if err := rule.apply(ctx, token, &d); err != nil {
+					failed++
+					result = "error: " + err.Error()
+				}
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s (%s)\t%s\n", rule.Name, rule.Action,
+				d.UpdatedAt.Format(time.DateOnly), d.Title, d.UrlID, result)
+		}
+	}
+	tw.Flush()
+	if err := writeResult(dstFile, buf.Bytes()); err != nil {
+		return err
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d action(s) failed, see the report", failed)
+	}
+	return nil
+}
+
+const policyHelp = `
+Policy file example:
+
+  rules:
+    - name: archive stale docs
+      collection: 6f35e6a2-0a41-4a1c-9f08-1e3b0b3e5f0c
+      untouchedFor: 18mo  # units: d, w, mo, y
+      action: archive     # archive, notify, or report
+    - name: ask owners to review
+      collection: Engineering  # collection id or name
+      untouchedFor: 6mo
+      action: notify      # leaves a comment addressed to document author
+      message: This document has not been updated for a while, please review it.
+`
+
+type policy struct {
+	Rules []*policyRule `yaml:"rules"`
+}
+
+type policyRule struct {
+	Name         string `yaml:"name"`
+	Collection   string `yaml:"collection"`
+	UntouchedFor string `yaml:"untouchedFor"`
+	Action       string `yaml:"action"`
+	Message      string `yaml:"message"`
+
+	age time.Duration // parsed UntouchedFor
+}
+
+func readPolicy(name string) (*policy, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var pol policy
+	if err := dec.Decode(&pol); err != nil {
+		return nil, fmt.Errorf("reading policy %s: %w", name, err)
+	}
+	for i, r := range pol.Rules {
+		if r.Name == "" {
+			r.Name = "rule #" + strconv.Itoa(i+1)
+		}
+		if r.Collection == "" {
+			return nil, fmt.Errorf("%s: collection must be set", r.Name)
+		}
+		if r.age, err = parseAge(r.UntouchedFor); err != nil {
+			return nil, fmt.Errorf("%s: %w", r.Name, err)
+		}
+		switch r.Action {
+		case "archive", "report":
+		case "notify":
+			if r.Message == "" {
+				return nil, fmt.Errorf("%s: notify action requires message", r.Name)
+			}
+		default:
+			return nil, fmt.Errorf("%s: unsupported action %q", r.Name, r.Action)
+		}
+	}
+	return &pol, nil
+}
+
+func (r *policyRule) apply(ctx context.Context, token authToken, d *document) error {
+	switch r.Action {
+	case "archive":
+		req := struct {
+			Id string `json:"id"`
+		}{Id: d.ID}
+		return apiCall(ctx, token, apiURL("documents.archive"), req)
+	case "notify":
+		text := r.Message
+		if d.CreatedBy.Name != "" {
+			text = d.CreatedBy.Name + ", " + text
+		}
+		_, err := createComment(ctx, token, d.ID, "", text)
+		return err
+	}
+	return nil
+}
+
+// parseAge parses durations like "90d", "6w", "18mo", "2y", falling back to
+// time.ParseDuration syntax. Months are treated as 30 days, years as 365 days.
+func parseAge(s string) (time.Duration, error) {
+	const day = 24 * time.Hour
+	units := []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"mo", 30 * day},
+		{"d", day},
+		{"w", 7 * day},
+		{"y", 365 * day},
+	}
+	for _, u := range units {
+		if v, ok := strings.CutSuffix(s, u.suffix); ok {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				return time.Duration(n) * u.unit, nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid age %q", s)
+}
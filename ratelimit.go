@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimiter throttles API requests when set with the global -rate-limit
+// flag or the OUTLINE_RATE_LIMIT environment variable; nil means no limit.
+var rateLimiter *tokenBucket
+
+// tokenBucket is a token bucket rate limiter safe for concurrent use
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64 // bucket capacity
+	tokens float64 // may become negative, accounting for waiting callers
+	last   time.Time
+}
+
+// parseRate parses rate limit given as "N", "N/s", "N/m", or "N/h" into the
+// token bucket allowing N requests per second, minute, or hour.
+func parseRate(s string) (*tokenBucket, error) {
+	num, unit, _ := strings.Cut(s, "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid rate limit %q", s)
+	}
+	var per time.Duration
+	switch unit {
+	case "", "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		return nil, fmt.Errorf("invalid rate limit %q: unit must be s, m, or h", s)
+	}
+	rate := n / per.Seconds()
+	burst := max(1, rate)
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}, nil
+}
+
+// wait blocks until the request can be made, or ctx is done. It is a no-op
+// on a nil bucket.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+	if delay == 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
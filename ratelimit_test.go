@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestParseRate(t *testing.T) {
+	for _, tc := range []struct {
+		s     string
+		rate  float64 // requests per second, 0 if s is invalid
+		burst float64
+	}{
+		{"10", 10, 10},
+		{"10/s", 10, 10},
+		{"0.5", 0.5, 1},
+		{"120/m", 2, 2},
+		{"30/m", 0.5, 1},
+		{"3600/h", 1, 1},
+		{"", 0, 0},
+		{"0", 0, 0},
+		{"-1/s", 0, 0},
+		{"ten/s", 0, 0},
+		{"10/d", 0, 0},
+	} {
+		b, err := parseRate(tc.s)
+		if tc.rate == 0 {
+			if err == nil {
+				t.Errorf("parseRate(%q) succeeded, want error", tc.s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRate(%q): %v", tc.s, err)
+			continue
+		}
+		if b.rate != tc.rate || b.burst != tc.burst {
+			t.Errorf("parseRate(%q) = rate %v, burst %v; want %v, %v", tc.s, b.rate, b.burst, tc.rate, tc.burst)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxRetries     = 5                      // how many times a request failed with a transient error is retried
+	retryBaseDelay = 500 * time.Millisecond // delay before the first retry, doubled on every subsequent one
+	retryMaxDelay  = 30 * time.Second
+	retryMaxWait   = 5 * time.Minute // longest Retry-After delay honored
+)
+
+// doRetry sends the request with http.DefaultClient, subject to rateLimiter,
+// retrying it on 429 Too Many Requests and 503 Service Unavailable responses,
+// and, if the request is idempotent, on other 5xx responses too, as these may
+// come after the server has already applied the change. Retry-After header
+// of the response is honored, otherwise retries are delayed by the
+// exponential backoff with jitter. If the next retry would not fit into the
+// request context deadline, or the server asks to wait longer than
+// retryMaxWait, the failed response is returned as is.
+//
+// With debugHTTP set, every attempt is dumped to stderr.
+//
+// Requests with body must have GetBody set, http.NewRequest does this for the
+// common in-memory body types.
+func doRetry(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if attempt != 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		if err := rateLimiter.wait(ctx); err != nil {
+			return nil, err
+		}
+		if debugHTTP {
+			debugRequest(req)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if debugHTTP && err == nil {
+			debugResponse(resp)
+		}
+		if err != nil || attempt == maxRetries || !retryableStatus(resp.StatusCode, idempotent(req)) || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+		delay := retryDelay(resp, attempt)
+		if delay > retryMaxWait {
+			return resp, nil
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return resp, nil
+		}
+		resp.Body.Close()
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func retryableStatus(code int, idempotent bool) bool {
+	switch {
+	case code == http.StatusTooManyRequests, code == http.StatusServiceUnavailable:
+		return true
+	case idempotent:
+		return code >= 500 && code != http.StatusNotImplemented
+	}
+	return false
+}
+
+// idempotentActions are API method actions, like "info" in "documents.info",
+// that have the same effect when applied more than once
+var idempotentActions = map[string]bool{
+	"info": true, "list": true, "search": true, "documents": true, "drafts": true,
+	"deleted": true, "archived": true, "redirect": true, "update": true, "move": true,
+	"archive": true, "unpublish": true, "restore": true, "revoke": true, "empty_trash": true,
+}
+
+// idempotent reports whether the request is safe to repeat. All API calls
+// are POST requests, so this is decided by the API method.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut:
+		return true
+	case http.MethodPost:
+		_, action, ok := strings.Cut(path.Base(req.URL.Path), ".")
+		return ok && idempotentActions[action]
+	}
+	return false
+}
+
+// retryDelay returns how long to wait before the next retry
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	if d, ok := retryAfter(resp); ok {
+		return d
+	}
+	d := min(retryBaseDelay<<attempt, retryMaxDelay)
+	// jitter spreads retries of concurrent clients
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryAfter returns the delay from the Retry-After response header, given
+// either in seconds or as a date. It reports false if the header is missing
+// or invalid.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	s := resp.Header.Get("Retry-After")
+	if s == "" {
+		return 0, false
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return time.Duration(n) * time.Second, true
+	}
+	if t, err := http.ParseTime(s); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"rsc.io/markdown"
+)
+
+func handleReviewNext(ctx context.Context, token authToken, cliargs []string) error {
+	collection := defaultCollection
+	var weighted, edit bool
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s review-next -collection id [flags]\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&collection, "collection", collection, "collection id or name to pick document from")
+	fs.BoolVar(&weighted, "weighted", weighted, "prefer documents with more views among the stale ones (slower, fetches views of every document)")
+	fs.BoolVar(&edit, "edit", edit, "open document in $EDITOR and update it after the editor exits")
+	fs.Parse(cliargs)
+	if collection == "" {
+		return errors.New("-collection flag must be set")
+	}
+	collection, err := collectionID(ctx, token, collection)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var best *document
+	var bestScore float64
+	for d, err := range listDocuments(ctx, token, collection, 0) {
+		if err != nil {
+			return err
+		}
+		score := now.Sub(d.UpdatedAt).Hours()
+		if weighted {
+			views, err := documentViews(ctx, token, d.ID)
+			if err != nil {
+				return err
+			}
+			score *= math.Log2(2 + float64(views))
+		}
+		if best == nil || score > bestScore {
+			best, bestScore = &d, score
+		}
+	}
+	if best == nil {
+		return errors.New("collection has no documents")
+	}
+	by := best.UpdatedBy.Name
+	if by == "" {
+		by = "unknown user"
+	}
+	fmt.Printf("%s\n%s\nlast updated %s by %s\n", best.Title, webURL(best.URL), best.UpdatedAt.Format(time.DateOnly), by)
+	if !edit {
+		return nil
+	}
+	return editDocument(ctx, token, best)
+}
+
+// documentViews returns the total number of document views
+func documentViews(ctx context.Context, token authToken, docID string) (int, error) {
+	req := struct {
+		Document string `json:"documentId"`
+	}{Document: docID}
+	res, err := apiRequest[[]struct {
+		Count int `json:"count"`
+	}](ctx, token, apiURL("views.list"), req)
+	if err != nil {
+		return 0, err
+	}
+	var total int
+	for _, v := range res {
+		total += v.Count
+	}
+	return total, nil
+}
+
+// editDocument opens document in the user's editor, and updates the document
+// if it was changed
+func editDocument(ctx context.Context, token authToken, d *document) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		return errors.New("neither VISUAL nor EDITOR environment variable is set")
+	}
+	dir, err := os.MkdirTemp("", "outline-edit-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, fileName(d.Title)+".md")
+	orig := documentMarkdown(d.Title, d.Text)
+	if err := os.WriteFile(name, orig, 0600); err != nil {
+		return err
+	}
+	cmd := shellCommand(ctx, editor, name)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running editor: %w", err)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, orig) {
+		fmt.Println("no changes")
+		return nil
+	}
+	title, doc := parseDocument(data, transformOptions{})
+	_, err = updateDocument(ctx, token, documentUpdate{Id: d.ID, Title: title, Text: markdown.Format(doc)})
+	return err
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"iter"
+	"os"
+	"time"
+)
+
+func handleRevisions(ctx context.Context, token authToken, cliargs []string) error {
+	var dstFile string
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s revisions [flags] url|urlid\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url or id")
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	d, err := documentInfo(ctx, token, urlid)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	out := []revision{}
+	for r, err := range listRevisions(ctx, token, d.ID) {
+		if err != nil {
+			return err
+		}
+		r.Text = "" // only list metadata
+		if jsonOutput {
+			out = append(out, r)
+			continue
+		}
+		fmt.Fprintf(&buf, "%s\t%s\t%s\t%s\n", r.ID, r.CreatedAt.Format(time.RFC3339), r.CreatedBy.Name, r.Title)
+	}
+	if jsonOutput {
+		return writeJSON(dstFile, out)
+	}
+	return writeResult(dstFile, buf.Bytes())
+}
+
+// revision holds a subset of document revision attributes
+type revision struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"createdAt"`
+	CreatedBy user      `json:"createdBy"`
+	Text      string    `json:"text,omitempty"`
+}
+
+// listRevisions iterates over revisions of the document, newest first
+func listRevisions(ctx context.Context, token authToken, docID string) iter.Seq2[revision, error] {
+	return paginate[revision](ctx, token, apiURL("revisions.list"), 0, func(p pagination) any {
+		return struct {
+			pagination
+			Document  string `json:"documentId"`
+			Sort      string `json:"sort"`
+			Direction string `json:"direction"`
+		}{pagination: p, Document: docID, Sort: "createdAt", Direction: "DESC"}
+	})
+}
+
+func handleRevDiff(ctx context.Context, token authToken, cliargs []string) error {
+	td := textDiff{context: 3}
+	colorMode := "auto"
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s revdiff [flags] url|urlid [revisionA [revisionB]]\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nWith two revisions, they are compared to each other. With one, it is compared with the current\n"+
+			"document. Without revisions, two latest revisions of the document are compared.")
+	}
+	fs.IntVar(&td.context, "context", td.context, "number of context lines to show")
+	fs.StringVar(&colorMode, "color", colorMode, "colorize diff: auto, always, or never")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if n := len(args) - loc.docArgs(); n < 0 || n > 2 {
+		return errors.New("want document url or id, optionally followed by one or two revision ids")
+	}
+	var err error
+	if td.color, err = useColor(colorMode, os.Stdout); err != nil {
+		return err
+	}
+	urlid, args, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	d, err := documentInfo(ctx, token, urlid)
+	if err != nil {
+		return err
+	}
+	var a, b *revision
+	switch len(args) {
+	case 0:
+		var ids []string
+		for r, err := range listRevisions(ctx, token, d.ID) {
+			if err != nil {
+				return err
+			}
+			if ids = append(ids, r.ID); len(ids) == 2 {
+				break
+			}
+		}
+		if len(ids) < 2 {
+			return errors.New("document has less than two revisions")
+		}
+		if b, err = revisionInfo(ctx, token, ids[0]); err != nil {
+			return err
+		}
+		if a, err = revisionInfo(ctx, token, ids[1]); err != nil {
+			return err
+		}
+	case 1:
+		if a, err = revisionInfo(ctx, token, args[0]); err != nil {
+			return err
+		}
+		b = &revision{Title: d.Title, Text: d.Text, CreatedAt: d.UpdatedAt}
+	case 2:
+		if a, err = revisionInfo(ctx, token, args[0]); err != nil {
+			return err
+		}
+		if b, err = revisionInfo(ctx, token, args[1]); err != nil {
+			return err
+		}
+	}
+	name := func(r *revision) string {
+		if r.ID == "" {
+			return "current"
+		}
+		return fmt.Sprintf("revision %s (%s)", r.ID, r.CreatedAt.Format(time.RFC3339))
+	}
+	if !td.write(os.Stdout, name(a), name(b), string(documentMarkdown(a.Title, a.Text)), string(documentMarkdown(b.Title, b.Text))) {
+		fmt.Println("no changes")
+	}
+	return nil
+}
+
+// revisionInfo fetches a single revision, including its text
+func revisionInfo(ctx context.Context, token authToken, id string) (*revision, error) {
+	req := struct {
+		Id string `json:"id"`
+	}{Id: id}
+	return apiRequest[*revision](ctx, token, apiURL("revisions.info"), req)
+}
+
+func handleRestore(ctx context.Context, token authToken, cliargs []string) error {
+	var revisionID string
+	var dryRun bool
+	td := textDiff{context: 3}
+	colorMode := "auto"
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s restore -revision id [flags] url|urlid\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&revisionID, "revision", revisionID, "id of the revision to restore document to, see revisions subcommand")
+	fs.BoolVar(&dryRun, "dry-run", dryRun, "do not restore document, only show the diff that would be applied")
+	fs.IntVar(&td.context, "context", td.context, "number of context lines to show in the -dry-run diff")
+	fs.StringVar(&colorMode, "color", colorMode, "colorize -dry-run diff: auto, always, or never")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url or id")
+	}
+	if revisionID == "" {
+		return errors.New("-revision flag must be set")
+	}
+	var err error
+	if td.color, err = useColor(colorMode, os.Stdout); err != nil {
+		return err
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		d, err := documentInfo(ctx, token, urlid)
+		if err != nil {
+			return err
+		}
+		r, err := revisionInfo(ctx, token, revisionID)
+		if err != nil {
+			return err
+		}
+		if !td.write(os.Stdout, "current", "revision "+r.ID, string(documentMarkdown(d.Title, d.Text)), string(documentMarkdown(r.Title, r.Text))) {
+			fmt.Println("no changes")
+		}
+		return nil
+	}
+	req := struct {
+		Id       string `json:"id"`
+		Revision string `json:"revisionId"`
+	}{Id: urlid, Revision: revisionID}
+	return apiCall(ctx, token, apiURL("documents.restore"), req)
+}
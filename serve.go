@@ -0,0 +1,117 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rsc.io/markdown"
+)
+
+func handleServe(ctx context.Context, _ authToken, cliargs []string) error {
+	addr := "localhost:8080"
+	var titleFlag string
+	var opts transformOptions
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s serve [flags] source-document.md\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nServes a preview of the file transformed the same way update does it, reloading the page when the file\n"+
+			"changes. Headings get Outline-style ids, so links to them resolve the way they will in Outline.")
+	}
+	fs.StringVar(&addr, "addr", addr, "address to listen on")
+	fs.BoolVar(&opts.numberHeadings, "number-headings", opts.numberHeadings, "prefix headings with hierarchical section numbers, see the same update flag")
+	fs.StringVar(&titleFlag, "title", titleFlag, "document `title`, see the same update flag")
+	fs.BoolVar(&opts.keepH1, "keep-h1", opts.keepH1, "keep the leading H1 heading in the document text")
+	fs.Parse(cliargs)
+	if fs.NArg() != 1 || fs.Arg(0) == "-" {
+		return errors.New("want source document file as the only positional argument")
+	}
+	name := fs.Arg(0)
+	var mu sync.Mutex
+	var page string
+	var version int
+	render := func() error {
+		title, doc, _, err := readDocument(name, opts)
+		var body string
+		if err == nil {
+			body = "<h1>" + html.EscapeString(cmp.Or(titleFlag, title)) + "</h1>\n" +
+				documentHTML(markdown.Format(doc), slugOutline)
+		} else {
+			body = "<pre>" + html.EscapeString(err.Error()) + "</pre>\n"
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		version++
+		page = strings.NewReplacer(
+			"{{title}}", html.EscapeString(name),
+			"{{version}}", strconv.Itoa(version),
+			"{{body}}", body,
+		).Replace(previewPage)
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		fmt.Fprint(w, page)
+	})
+	// local images are served relative to the file
+	mux.Handle("GET /", http.FileServer(http.Dir(filepath.Dir(name))))
+	mux.HandleFunc("GET /version", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Cache-Control", "no-store")
+		fmt.Fprint(w, version)
+	})
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	log.Printf("serving preview of %s on http://%s/", name, addr)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() { errc <- watchFile(ctx, name, render) }()
+	return <-errc
+}
+
+// previewPage is the HTML page template of the serve subcommand, it polls for
+// the page version to reload itself when the file changes
+const previewPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{title}}</title>
+<style>
+body { max-width: 46em; margin: 2em auto; padding: 0 1em; font-family: system-ui, sans-serif; line-height: 1.5; }
+pre, code { background: #f4f4f4; }
+pre { padding: 0.5em; overflow-x: auto; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.5em; }
+img { max-width: 100%; }
+</style>
+</head>
+<body>
+{{body}}<script>
+const version = "{{version}}";
+setInterval(async () => {
+	try {
+		const resp = await fetch("/version");
+		if (resp.ok && await resp.text() !== version) location.reload();
+	} catch (e) {}
+}, 1000);
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"iter"
+	"time"
+)
+
+func handleShare(ctx context.Context, token authToken, cliargs []string) error {
+	usage := fmt.Errorf("usage: %s share create|list|revoke [flags] ...", exeName)
+	if len(cliargs) == 0 {
+		return usage
+	}
+	switch cliargs[0] {
+	case "create":
+		var publish bool
+		var loc docLocator
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s share create [-publish] url|urlid\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.BoolVar(&publish, "publish", publish, "make the share link accessible to anyone, not only to the workspace members")
+		loc.register(fs)
+		args := parseInterspersed(fs, cliargs[1:])
+		if len(args) != loc.docArgs() {
+			return errors.New("want exactly one document url or id")
+		}
+		urlid, _, err := loc.resolve(ctx, token, args)
+		if err != nil {
+			return err
+		}
+		d, err := documentInfo(ctx, token, urlid)
+		if err != nil {
+			return err
+		}
+		req := struct {
+			Document  string `json:"documentId"`
+			Published bool   `json:"published"`
+		}{Document: d.ID, Published: publish}
+		s, err := apiRequest[*share](ctx, token, apiURL("shares.create"), req)
+		if err != nil {
+			return err
+		}
+		// shares.create returns an existing share as is
+		if s.Published != publish {
+			req := struct {
+				Id        string `json:"id"`
+				Published bool   `json:"published"`
+			}{Id: s.ID, Published: publish}
+			if s, err = apiRequest[*share](ctx, token, apiURL("shares.update"), req); err != nil {
+				return err
+			}
+		}
+		if jsonOutput {
+			return writeJSON("", s)
+		}
+		fmt.Printf("%s\t%s\n", s.ID, s.URL)
+		return nil
+	case "list":
+		var dstFile, docID string
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s share list [flags]\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+		fs.StringVar(&docID, "document", docID, "only list shares of this document url|urlid")
+		fs.Parse(cliargs[1:])
+		if docID != "" {
+			d, err := documentInfo(ctx, token, documentID(docID))
+			if err != nil {
+				return err
+			}
+			docID = d.ID
+		}
+		var buf bytes.Buffer
+		out := []share{}
+		for s, err := range listShares(ctx, token) {
+			if err != nil {
+				return err
+			}
+			if docID != "" && s.DocumentID != docID {
+				continue
+			}
+			if jsonOutput {
+				out = append(out, s)
+				continue
+			}
+			access := "private"
+			if s.Published {
+				access = "public"
+			}
+			fmt.Fprintf(&buf, "%s\t%s\t%s\t%s\n", s.ID, access, s.URL, s.DocumentTitle)
+		}
+		if jsonOutput {
+			return writeJSON(dstFile, out)
+		}
+		return writeResult(dstFile, buf.Bytes())
+	case "revoke":
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s share revoke share-id\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.Parse(cliargs[1:])
+		if fs.NArg() != 1 {
+			return errors.New("want exactly one share id, see share list")
+		}
+		req := struct {
+			Id string `json:"id"`
+		}{Id: fs.Arg(0)}
+		return apiCall(ctx, token, apiURL("shares.revoke"), req)
+	}
+	return usage
+}
+
+func listShares(ctx context.Context, token authToken) iter.Seq2[share, error] {
+	return paginate[share](ctx, token, apiURL("shares.list"), 0, func(p pagination) any { return p })
+}
+
+// share holds a subset of document share link attributes
+type share struct {
+	ID            string    `json:"id"`
+	DocumentID    string    `json:"documentId"`
+	DocumentTitle string    `json:"documentTitle"`
+	URL           string    `json:"url"` // absolute public url
+	Published     bool      `json:"published"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
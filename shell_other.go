@@ -0,0 +1,17 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"os/exec"
+)
+
+// shellCommand returns the command running command line with the shell, args
+// are appended to it as separate arguments
+func shellCommand(ctx context.Context, command string, args ...string) *exec.Cmd {
+	if len(args) != 0 {
+		command += ` "$@"`
+	}
+	return exec.CommandContext(ctx, "/bin/sh", append([]string{"-c", command, "sh"}, args...)...)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand returns the command running command line with cmd.exe, args
+// are appended to it as separate arguments
+func shellCommand(ctx context.Context, command string, args ...string) *exec.Cmd {
+	for _, s := range args {
+		command += " " + syscall.EscapeArg(s)
+	}
+	shell := cmp.Or(os.Getenv("ComSpec"), "cmd.exe")
+	cmd := exec.CommandContext(ctx, shell)
+	// cmd.exe does not follow the usual quoting rules, so the command line
+	// is passed as is; with /S, only the outer quotes are stripped
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: syscall.EscapeArg(shell) + ` /S /C "` + command + `"`}
+	return cmd
+}
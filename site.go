@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+func handleSite(ctx context.Context, token authToken, cliargs []string) error {
+	var dstDir string
+	collection := defaultCollection
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s site -collection id -o directory\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nRenders all collection documents as HTML pages with navigation reflecting the collection\n"+
+			"structure, producing a read-only static mirror. Links between collection documents are rewritten\n"+
+			"to the local pages, images attached to documents are saved to the assets subdirectory.")
+	}
+	fs.StringVar(&collection, "collection", collection, "id or name of the collection to render")
+	fs.StringVar(&dstDir, "o", dstDir, "`directory` to write the site to")
+	fs.Parse(cliargs)
+	if collection == "" {
+		return errors.New("-collection flag must be set")
+	}
+	if dstDir == "" {
+		return errors.New("-o flag must be set")
+	}
+	var err error
+	if collection, err = collectionID(ctx, token, collection); err != nil {
+		return err
+	}
+	c, err := collectionInfo(ctx, token, collection)
+	if err != nil {
+		return err
+	}
+	nodes, err := collectionTree(ctx, token, collection)
+	if err != nil {
+		return err
+	}
+	pages := make(map[string]string) // document id and urlId to page file name
+	var index func(nodes []navNode)
+	index = func(nodes []navNode) {
+		for _, n := range nodes {
+			name := sitePageName(n.URL)
+			pages[n.ID], pages[documentID(n.URL)] = name, name
+			index(n.Children)
+		}
+	}
+	index(nodes)
+	if err := os.MkdirAll(dstDir, 0777); err != nil {
+		return err
+	}
+	write := func(name, title, current, body string) error {
+		page := strings.NewReplacer(
+			"{{title}}", html.EscapeString(title),
+			"{{collection}}", html.EscapeString(c.Name),
+			"{{nav}}", siteNav(nodes, current),
+			"{{body}}", body,
+		).Replace(sitePage)
+		return os.WriteFile(filepath.Join(dstDir, name), []byte(page), 0666)
+	}
+	var p markdown.Parser
+	var walk func(nodes []navNode) error
+	walk = func(nodes []navNode) error {
+		for _, n := range nodes {
+			d, err := documentInfo(ctx, token, n.ID)
+			if err != nil {
+				return err
+			}
+			doc := p.Parse(d.Text)
+			for link := range docLinks(doc) {
+				s, ok := strings.CutPrefix(strings.TrimPrefix(link.URL, webURL("")), "/doc/")
+				if !ok {
+					continue
+				}
+				s, anchor, _ := strings.Cut(s, "#")
+				if name, ok := pages[documentID(s)]; ok {
+					link.URL = name
+					if anchor != "" {
+						link.URL += "#" + anchor
+					}
+				}
+			}
+			// attachments of different documents often have the same file
+			// names, so each document gets its own assets directory
+			if err := downloadImages(ctx, token, doc, filepath.Join(dstDir, "assets", d.UrlID), dstDir); err != nil {
+				return err
+			}
+			// headings get Outline-style ids, so links to them keep working
+			// without being rewritten
+			body := "<h1>" + html.EscapeString(d.Title) + "</h1>\n" + documentHTML(markdown.Format(doc), slugOutline)
+			if err := write(pages[n.ID], d.Title, pages[n.ID], body); err != nil {
+				return err
+			}
+			fmt.Println(pages[n.ID])
+			if err := walk(n.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(nodes); err != nil {
+		return err
+	}
+	body := "<h1>" + html.EscapeString(c.Name) + "</h1>\n" + documentHTML(c.Description, slugOutline)
+	return write("index.html", c.Name, "index.html", body)
+}
+
+// sitePageName returns the file name of the static site page for the document
+// with the given url
+func sitePageName(url string) string {
+	return path.Base(url) + ".html"
+}
+
+// siteNav renders collection structure as nested HTML lists linking to the
+// site pages, with the current page link marked
+func siteNav(nodes []navNode, current string) string {
+	if len(nodes) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<ul>\n")
+	for _, n := range nodes {
+		name := sitePageName(n.URL)
+		b.WriteString("<li><a href=\"" + html.EscapeString(name) + "\"")
+		if name == current {
+			b.WriteString(` class="current"`)
+		}
+		b.WriteString(">" + html.EscapeString(n.Title) + "</a>\n")
+		b.WriteString(siteNav(n.Children, current))
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</ul>\n")
+	return b.String()
+}
+
+const sitePage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{title}}</title>
+<style>
+body { display: flex; margin: 0; font-family: system-ui, sans-serif; line-height: 1.5; }
+nav { flex: 0 0 16em; padding: 1em; border-right: 1px solid #ddd; min-height: 100vh; }
+nav ul { list-style: none; padding-left: 1em; margin: 0; }
+nav > ul { padding-left: 0; }
+nav a { text-decoration: none; }
+nav a.current { font-weight: bold; }
+main { max-width: 46em; padding: 0 2em 2em; }
+pre, code { background: #f4f4f4; }
+pre { padding: 0.5em; overflow-x: auto; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.5em; }
+img { max-width: 100%; }
+</style>
+</head>
+<body>
+<nav>
+<p><a href="index.html">{{collection}}</a></p>
+{{nav}}</nav>
+<main>
+{{body}}</main>
+</body>
+</html>
+`
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+func handleSplit(ctx context.Context, token authToken, cliargs []string) error {
+	by := "h2"
+	var dryRun bool
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s split [flags] url|urlid\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nEach section starting with the heading of the given level becomes a child document titled after\n"+
+			"the heading, with its subheadings promoted one level. The original document keeps the text outside\n"+
+			"of such sections, followed by the list of links to the new documents. Links to headings are updated.")
+	}
+	fs.StringVar(&by, "by", by, "heading `level` to split document by, h1 to h6")
+	fs.BoolVar(&dryRun, "dry-run", dryRun, "do not change anything, only print titles of the documents to be created")
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return errors.New("want exactly one document url or id")
+	}
+	var level int
+	if _, err := fmt.Sscanf(strings.ToLower(by), "h%d", &level); err != nil || level < 1 || level > 6 {
+		return fmt.Errorf("invalid -by value %q, want h1 to h6", by)
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return err
+	}
+	d, err := documentInfo(ctx, token, urlid)
+	if err != nil {
+		return err
+	}
+	var p markdown.Parser
+	doc := p.Parse(d.Text)
+	parts := splitSections(doc, level)
+	if len(parts) == 1 {
+		return fmt.Errorf("document has no level %d headings", level)
+	}
+	if dryRun {
+		for _, part := range parts[1:] {
+			fmt.Println(part.title)
+		}
+		return nil
+	}
+	parts[0].url = d.URL
+	for _, part := range parts[1:] {
+		child, err := createDocument(ctx, token, newDocument{
+			Title:      part.title,
+			Collection: d.CollectionID,
+			Parent:     d.ID,
+			Publish:    d.PublishedAt != nil,
+		})
+		if err != nil {
+			return err
+		}
+		part.id, part.url = child.ID, child.URL
+	}
+	// links to headings of the original document, by outline-style slug, to
+	// their new locations
+	type target struct {
+		part *docPart
+		slug string // empty for section headings that became document titles
+	}
+	targets := make(map[string]target)
+	orig := headingSlugs(doc, slugOutline)
+	for _, part := range parts {
+		if part.heading >= 0 {
+			targets["#"+orig[part.heading]] = target{part: part}
+		}
+		for i, s := range headingSlugs(part.doc, slugOutline) {
+			targets["#"+orig[part.headings[i]]] = target{part: part, slug: s}
+		}
+	}
+	for _, part := range parts {
+		for link := range docLinks(part.doc) {
+			t, ok := targets[link.URL]
+			if !ok {
+				continue
+			}
+			switch {
+			case t.part == part:
+				link.URL = "#" + t.slug
+			case t.slug == "":
+				link.URL = t.part.url
+			default:
+				link.URL = t.part.url + "#" + t.slug
+			}
+		}
+	}
+	for _, part := range parts[1:] {
+		if _, err := updateDocument(ctx, token, documentUpdate{Id: part.id, Text: markdown.Format(part.doc)}); err != nil {
+			return err
+		}
+	}
+	var sb strings.Builder
+	if len(parts[0].doc.Blocks) != 0 {
+		sb.WriteString(markdown.Format(parts[0].doc))
+		sb.WriteString("\n")
+	}
+	for _, part := range parts[1:] {
+		fmt.Fprintf(&sb, "- [%s](%s)\n", escapeLinkText(part.title), part.url)
+	}
+	_, err = updateDocument(ctx, token, documentUpdate{Id: d.ID, Text: sb.String()})
+	return err
+}
+
+// docPart is a piece of the document being split
+type docPart struct {
+	title    string
+	doc      *markdown.Document
+	heading  int   // index of the section heading among top-level document headings, -1 for the first part
+	headings []int // indexes of part.doc headings among top-level headings of the original document
+	id, url  string
+}
+
+// splitSections splits document into parts: the first one holds blocks
+// outside of any level-deep section, every other part is a section starting
+// with the heading of that level, without the heading itself. Headings
+// inside sections are promoted one level.
+func splitSections(doc *markdown.Document, level int) []*docPart {
+	parts := []*docPart{{doc: new(markdown.Document), heading: -1}}
+	cur := parts[0]
+	hi := -1
+	for _, b := range doc.Blocks {
+		h, ok := b.(*markdown.Heading)
+		if !ok {
+			cur.doc.Blocks = append(cur.doc.Blocks, b)
+			continue
+		}
+		hi++
+		switch {
+		case h.Level == level:
+			cur = &docPart{title: inlinesText(h.Text.Inline), doc: new(markdown.Document), heading: hi}
+			parts = append(parts, cur)
+			continue
+		case h.Level < level:
+			cur = parts[0]
+		default:
+			if cur != parts[0] && h.Level > 1 {
+				h.Level--
+			}
+		}
+		cur.doc.Blocks = append(cur.doc.Blocks, b)
+		cur.headings = append(cur.headings, hi)
+	}
+	return parts
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"rsc.io/markdown"
+)
+
+func TestSplitSections(t *testing.T) {
+	type part struct {
+		title    string
+		heading  int
+		headings []int
+		text     string
+	}
+	for _, tc := range []struct {
+		src   string
+		level int
+		want  []part
+	}{
+		{
+			src:   "Intro\n\n# One\n\ntext\n\n## One sub\n\n### deeper\n\n# Two\n\n## Two sub\n",
+			level: 1,
+			want: []part{
+				{"", -1, nil, "Intro\n"},
+				{"One", 0, []int{1, 2}, "text\n\n# One sub\n\n## deeper\n"},
+				{"Two", 3, []int{4}, "# Two sub\n"},
+			},
+		},
+		{
+			// headings above the split level go to the first part
+			src:   "# Top\n\n## A\n\na\n\n## B\n\n### B1\n\n# Top2\n\nx\n",
+			level: 2,
+			want: []part{
+				{"", -1, []int{0, 4}, "# Top\n\n# Top2\n\nx\n"},
+				{"A", 1, nil, "a\n"},
+				{"B", 2, []int{3}, "## B1\n"},
+			},
+		},
+		{
+			src:   "no sections\n",
+			level: 1,
+			want:  []part{{"", -1, nil, "no sections\n"}},
+		},
+	} {
+		var p markdown.Parser
+		parts := splitSections(p.Parse(tc.src), tc.level)
+		if len(parts) != len(tc.want) {
+			t.Errorf("splitSections(%q, %d) returned %d parts, want %d", tc.src, tc.level, len(parts), len(tc.want))
+			continue
+		}
+		for i, w := range tc.want {
+			got := part{parts[i].title, parts[i].heading, parts[i].headings, markdown.Format(parts[i].doc)}
+			if got.title != w.title || got.heading != w.heading || !slices.Equal(got.headings, w.headings) || got.text != w.text {
+				t.Errorf("splitSections(%q, %d) part %d = %+v, want %+v", tc.src, tc.level, i, got, w)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"iter"
+)
+
+func handleStar(ctx context.Context, token authToken, cliargs []string) error {
+	d, err := docArgument(ctx, token, "star", cliargs)
+	if err != nil {
+		return err
+	}
+	req := struct {
+		Document string `json:"documentId"`
+	}{Document: d.ID}
+	return apiCall(ctx, token, apiURL("stars.create"), req)
+}
+
+func handleUnstar(ctx context.Context, token authToken, cliargs []string) error {
+	d, err := docArgument(ctx, token, "unstar", cliargs)
+	if err != nil {
+		return err
+	}
+	for s, err := range listStars(ctx, token) {
+		if err != nil {
+			return err
+		}
+		if s.DocumentID != d.ID {
+			continue
+		}
+		req := struct {
+			Id string `json:"id"`
+		}{Id: s.ID}
+		return apiCall(ctx, token, apiURL("stars.delete"), req)
+	}
+	return fmt.Errorf("document %q is not starred", d.Title)
+}
+
+func handleStarred(ctx context.Context, token authToken, cliargs []string) error {
+	var dstFile string
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s starred [flags]\n", exeName)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+	fs.Parse(cliargs)
+	docs := func(yield func(document, error) bool) {
+		for s, err := range listStars(ctx, token) {
+			if err != nil {
+				yield(document{}, err)
+				return
+			}
+			if s.document.ID != "" && !yield(s.document, nil) {
+				return
+			}
+		}
+	}
+	return writeDocuments(dstFile, docs)
+}
+
+// docArgument parses flags of a subcommand that only takes a single document
+// url|urlid argument, and fetches that document
+func docArgument(ctx context.Context, token authToken, name string, cliargs []string) (*document, error) {
+	var loc docLocator
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s url|urlid\n", exeName, name)
+		fs.PrintDefaults()
+	}
+	loc.register(fs)
+	args := parseInterspersed(fs, cliargs)
+	if len(args) != loc.docArgs() {
+		return nil, errors.New("want exactly one document url or id")
+	}
+	urlid, _, err := loc.resolve(ctx, token, args)
+	if err != nil {
+		return nil, err
+	}
+	return documentInfo(ctx, token, urlid)
+}
+
+// star is a document or collection starred by the user
+type star struct {
+	ID           string `json:"id"`
+	DocumentID   string `json:"documentId"`
+	CollectionID string `json:"collectionId"`
+
+	document document // starred document, if DocumentID is set
+}
+
+// listStars iterates over stars of the user, in their sidebar order
+func listStars(ctx context.Context, token authToken) iter.Seq2[star, error] {
+	const pageSize = 100
+	return func(yield func(star, error) bool) {
+		for offset := 0; ; offset += pageSize {
+			res, err := apiRequest[struct {
+				Stars     []star     `json:"stars"`
+				Documents []document `json:"documents"`
+			}](ctx, token, apiURL("stars.list"), pagination{Limit: pageSize, Offset: offset})
+			if err != nil {
+				yield(star{}, err)
+				return
+			}
+			docs := make(map[string]document, len(res.Documents))
+			for _, d := range res.Documents {
+				docs[d.ID] = d
+			}
+			for _, s := range res.Stars {
+				s.document = docs[s.DocumentID]
+				if !yield(s, nil) {
+					return
+				}
+			}
+			if len(res.Stars) < pageSize {
+				return
+			}
+		}
+	}
+}
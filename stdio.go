@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"rsc.io/markdown"
+)
+
+func handleStdioServer(ctx context.Context, token authToken, cliargs []string) error {
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s stdio-server\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), stdioServerHelp)
+	}
+	fs.Parse(cliargs)
+	srv := &rpcServer{token: token}
+	return srv.serve(ctx, os.Stdin, os.Stdout)
+}
+
+const stdioServerHelp = `
+Reads JSON-RPC 2.0 requests from stdin, one per line, and writes responses
+to stdout, one per line. Supported methods and their params:
+
+  get           {"id": "url|urlid"}
+                returns {"id", "urlId", "title", "text", "updatedAt", "markdown", "icon", "fullWidth"},
+                where markdown is the document as saved by the get subcommand
+  update        {"id": "url|urlid", "markdown": "...", "dryRun": false}
+                transforms markdown the same way update subcommand does it,
+                returns {"id", "urlId", "title", "updatedAt"}, or {"title", "text"}
+                that would have been sent if dryRun is true
+  search        {"query": "...", "limit": 25, "collection": "id or name"}
+                returns [{"title", "urlId", "context"}]
+  resolveLink   {"url": "document url"}
+                returns {"id", "urlId", "title"}
+`
+
+type rpcServer struct {
+	token authToken
+}
+
+type rpcRequest struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+func (s *rpcServer) serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), int(maxResponseSize))
+	enc := json.NewEncoder(w)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			if err := enc.Encode(rpcResponse{Version: "2.0", ID: json.RawMessage("null"),
+				Error: &rpcError{Code: rpcParseError, Message: err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+		result, err := s.call(ctx, &req)
+		if req.ID == nil {
+			continue // notification, no response expected
+		}
+		resp := rpcResponse{Version: "2.0", ID: req.ID, Result: result}
+		if err != nil {
+			var re *rpcError
+			if !errors.As(err, &re) {
+				re = &rpcError{Code: rpcServerError, Message: err.Error()}
+			}
+			resp.Result, resp.Error = nil, re
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+func (s *rpcServer) call(ctx context.Context, req *rpcRequest) (any, error) {
+	if req.Version != "2.0" || req.Method == "" {
+		return nil, &rpcError{Code: rpcInvalidRequest, Message: "invalid JSON-RPC 2.0 request"}
+	}
+	params := func(v any) error {
+		if len(req.Params) == 0 {
+			return &rpcError{Code: rpcInvalidParams, Message: "missing params"}
+		}
+		if err := json.Unmarshal(req.Params, v); err != nil {
+			return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		return nil
+	}
+	switch req.Method {
+	case "get":
+		var p struct {
+			ID string `json:"id"`
+		}
+		if err := params(&p); err != nil {
+			return nil, err
+		}
+		d, err := documentInfo(ctx, s.token, documentID(p.ID))
+		if err != nil {
+			return nil, err
+		}
+		return struct {
+			ID        string    `json:"id"`
+			UrlID     string    `json:"urlId"`
+			Title     string    `json:"title"`
+			Text      string    `json:"text"`
+			UpdatedAt time.Time `json:"updatedAt"`
+			Markdown  string    `json:"markdown"`
+			Icon      string    `json:"icon,omitempty"`
+			FullWidth bool      `json:"fullWidth"`
+		}{d.ID, d.UrlID, d.Title, d.Text, d.UpdatedAt, string(documentMarkdown(d.Title, localText(d.Text))), d.icon(), d.FullWidth}, nil
+	case "update":
+		var p struct {
+			ID       string `json:"id"`
+			Markdown string `json:"markdown"`
+			DryRun   bool   `json:"dryRun"`
+		}
+		if err := params(&p); err != nil {
+			return nil, err
+		}
+		title, doc := parseDocument([]byte(p.Markdown), transformOptions{})
+		du := documentUpdate{Id: documentID(p.ID), Title: title, Text: markdown.Format(doc)}
+		if p.DryRun {
+			return struct {
+				Title string `json:"title"`
+				Text  string `json:"text"`
+			}{du.Title, du.Text}, nil
+		}
+		d, err := updateDocument(ctx, s.token, du)
+		if err != nil {
+			return nil, err
+		}
+		return struct {
+			ID        string    `json:"id"`
+			UrlID     string    `json:"urlId"`
+			Title     string    `json:"title"`
+			UpdatedAt time.Time `json:"updatedAt"`
+		}{d.ID, d.UrlID, d.Title, d.UpdatedAt}, nil
+	case "search":
+		var p struct {
+			Query      string `json:"query"`
+			Limit      int    `json:"limit"`
+			Collection string `json:"collection"`
+		}
+		if err := params(&p); err != nil {
+			return nil, err
+		}
+		if p.Limit <= 0 {
+			p.Limit = 25
+		}
+		collection, err := collectionID(ctx, s.token, p.Collection)
+		if err != nil {
+			return nil, err
+		}
+		results, err := searchDocuments(ctx, s.token, searchQuery{Query: p.Query, Limit: p.Limit, Collection: collection})
+		if err != nil {
+			return nil, err
+		}
+		type item struct {
+			Title   string `json:"title"`
+			UrlID   string `json:"urlId"`
+			Context string `json:"context"`
+		}
+		out := make([]item, 0, len(results))
+		for _, r := range results {
+			out = append(out, item{r.Document.Title, r.Document.UrlID, r.Context})
+		}
+		return out, nil
+	case "resolveLink":
+		var p struct {
+			URL string `json:"url"`
+		}
+		if err := params(&p); err != nil {
+			return nil, err
+		}
+		id, _, ok := documentLink(p.URL)
+		if !ok {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("%q is not a document link", p.URL)}
+		}
+		d, err := documentInfo(ctx, s.token, id)
+		if err != nil {
+			return nil, err
+		}
+		return struct {
+			ID    string `json:"id"`
+			UrlID string `json:"urlId"`
+			Title string `json:"title"`
+		}{d.ID, d.UrlID, d.Title}, nil
+	}
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+}
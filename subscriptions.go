@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+)
+
+// subscriptionEvent is the only event documents can be subscribed to
+const subscriptionEvent = "documents.update"
+
+func handleSubscribe(ctx context.Context, token authToken, cliargs []string) error {
+	d, err := docArgument(ctx, token, "subscribe", cliargs)
+	if err != nil {
+		return err
+	}
+	req := struct {
+		Document string `json:"documentId"`
+		Event    string `json:"event"`
+	}{Document: d.ID, Event: subscriptionEvent}
+	return apiCall(ctx, token, apiURL("subscriptions.create"), req)
+}
+
+func handleUnsubscribe(ctx context.Context, token authToken, cliargs []string) error {
+	d, err := docArgument(ctx, token, "unsubscribe", cliargs)
+	if err != nil {
+		return err
+	}
+	req := struct {
+		Document string `json:"documentId"`
+		Event    string `json:"event"`
+	}{Document: d.ID, Event: subscriptionEvent}
+	res, err := apiRequest[struct {
+		ID string `json:"id"`
+	}](ctx, token, apiURL("subscriptions.info"), req)
+	if err != nil {
+		return err
+	}
+	del := struct {
+		Id string `json:"id"`
+	}{Id: res.ID}
+	return apiCall(ctx, token, apiURL("subscriptions.delete"), del)
+}
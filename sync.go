@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+func handleSync(ctx context.Context, token authToken, cliargs []string) error {
+	var dryRun bool
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s sync [flags] directory\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nFiles are mapped to documents by the %s file in the directory,\n"+
+			"see the import-dir subcommand. Files changed locally since the last sync are uploaded,\n"+
+			"documents changed remotely are downloaded. If both the file and the document\n"+
+			"changed, the conflict is reported and neither side is modified.\n"+
+			"New local files are created as documents the way import-dir does it, and documents\n"+
+			"added to the collection are downloaded to files named after their titles, in\n"+
+			"directories named after their parent documents.\n", manifestName)
+	}
+	fs.BoolVar(&dryRun, "dry-run", dryRun, "only report what would be done")
+	args := parseInterspersed(fs, cliargs)
+	if len(args) == 0 {
+		return errors.New("want directory as the first positional argument")
+	}
+	dir := args[0]
+	m, err := readManifest(dir)
+	if err != nil {
+		return err
+	}
+	if len(m.Documents) == 0 && m.Collection == "" {
+		return fmt.Errorf("no documents tracked in %s, use import-dir first", filepath.Join(dir, manifestName))
+	}
+	ld, err := loadLocalDocs(dir)
+	if err != nil {
+		return err
+	}
+	// download saves the document to the file name and records it in the
+	// manifest
+	download := func(name string, d *document) error {
+		localName := filepath.Join(dir, filepath.FromSlash(name))
+		var p markdown.Parser
+		doc := p.Parse(d.Text)
+		text := d.Text
+		changed, err := ld.toLocal(ctx, token, doc, filepath.Dir(localName))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if changed {
+			text = markdown.Format(doc)
+		}
+		data := documentMarkdown(d.Title, text)
+		if err := os.MkdirAll(filepath.Dir(localName), 0777); err != nil {
+			return err
+		}
+		if err := os.WriteFile(localName, data, 0666); err != nil {
+			return err
+		}
+		m.Documents[name] = &manifestEntry{ID: d.ID, UpdatedAt: d.UpdatedAt, Hash: contentHash(data)}
+		return m.save(dir)
+	}
+	var conflicts int
+	for _, name := range slices.Sorted(maps.Keys(m.Documents)) {
+		e := m.Documents[name]
+		if e.Hash == "" {
+			continue // directory placeholder document
+		}
+		localName := filepath.Join(dir, filepath.FromSlash(name))
+		data, err := os.ReadFile(localName)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		d, err := documentInfo(ctx, token, e.ID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		localChanged := data != nil && contentHash(data) != e.Hash
+		remoteChanged := d.UpdatedAt.After(e.UpdatedAt) || data == nil
+		switch {
+		case localChanged && remoteChanged:
+			conflicts++
+			fmt.Printf("conflict\t%s\n", name)
+		case localChanged:
+			fmt.Printf("upload\t%s\n", name)
+			if dryRun {
+				continue
+			}
+			title, doc := parseDocument(data, transformOptions{})
+			if _, err := ld.toOutline(ctx, token, doc, filepath.Dir(localName)); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			d, err := updateDocument(ctx, token, documentUpdate{Id: e.ID, Title: title, Text: markdown.Format(doc)})
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			e.UpdatedAt, e.Hash = d.UpdatedAt, contentHash(data)
+		case remoteChanged:
+			fmt.Printf("download\t%s\n", name)
+			if dryRun {
+				continue
+			}
+			if err := download(name, d); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+		if err := m.save(dir); err != nil {
+			return err
+		}
+	}
+	files, err := markdownFiles(dir)
+	if err != nil {
+		return err
+	}
+	if m.Collection == "" {
+		// manifests written before the collection was recorded
+		for _, name := range files {
+			if _, ok := m.Documents[name]; !ok {
+				fmt.Printf("untracked\t%s\n", name)
+			}
+		}
+	} else {
+		if dryRun {
+			for _, name := range files {
+				if _, ok := m.Documents[name]; !ok {
+					fmt.Printf("create\t%s\n", name)
+				}
+			}
+		} else if _, err := importFiles(ctx, token, dir, m, files, func(name string, _ *document) {
+			fmt.Printf("create\t%s\n", name)
+		}); err != nil {
+			return err
+		}
+		if err := pullNewDocuments(ctx, token, m, files, dryRun, download); err != nil {
+			return err
+		}
+	}
+	if conflicts != 0 {
+		return fmt.Errorf("%d document(s) changed both locally and remotely", conflicts)
+	}
+	return nil
+}
+
+// pullNewDocuments calls download for documents of the m.Collection not yet
+// tracked by the manifest. Each document is named after its title, in the
+// directory named after its parent document; names are picked so they do not
+// clash with the tracked names and local files.
+func pullNewDocuments(ctx context.Context, token authToken, m *manifest, files []string, dryRun bool, download func(name string, d *document) error) error {
+	tree, err := collectionTree(ctx, token, m.Collection)
+	if err != nil {
+		return err
+	}
+	tracked := make(map[string]string, len(m.Documents)) // document id to name
+	seen := make(map[string]struct{})                    // names without the .md extension
+	for name, e := range m.Documents {
+		tracked[e.ID] = name
+		seen[strings.ToLower(strings.TrimSuffix(name, ".md"))] = struct{}{}
+	}
+	for _, name := range files {
+		seen[strings.ToLower(strings.TrimSuffix(name, ".md"))] = struct{}{}
+	}
+	var walk func(nodes []navNode, parentDir string) error
+	walk = func(nodes []navNode, parentDir string) error {
+		for _, n := range nodes {
+			name, ok := tracked[n.ID]
+			if !ok {
+				name = uniqueName(seen, path.Join(parentDir, fileName(n.Title))) + ".md"
+				fmt.Printf("download\t%s\n", name)
+				if !dryRun {
+					d, err := documentInfo(ctx, token, n.ID)
+					if err != nil {
+						return fmt.Errorf("%s: %w", name, err)
+					}
+					if err := download(name, d); err != nil {
+						return err
+					}
+				}
+			}
+			if err := walk(n.Children, strings.TrimSuffix(name, ".md")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(tree, "")
+}
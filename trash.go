@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+func handleTrash(ctx context.Context, token authToken, cliargs []string) error {
+	usage := fmt.Errorf("usage: %s trash list|restore|empty [flags] ...", exeName)
+	if len(cliargs) == 0 {
+		return usage
+	}
+	switch cliargs[0] {
+	case "list":
+		var dstFile string
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s trash list [flags]\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+		fs.Parse(cliargs[1:])
+		deleted := paginate[document](ctx, token, apiURL("documents.deleted"), 0, func(p pagination) any { return p })
+		return writeDocuments(dstFile, deleted)
+	case "restore":
+		var collection string
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s trash restore [flags] url|urlid\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&collection, "collection", collection, "id or name of the collection to restore document to, if its original collection was deleted")
+		args := parseInterspersed(fs, cliargs[1:])
+		if len(args) != 1 {
+			return errors.New("want exactly one document url or id, see trash list")
+		}
+		var err error
+		if collection, err = collectionID(ctx, token, collection); err != nil {
+			return err
+		}
+		req := struct {
+			Id         string `json:"id"`
+			Collection string `json:"collectionId,omitempty"`
+		}{Id: documentID(args[0]), Collection: collection}
+		return apiCall(ctx, token, apiURL("documents.restore"), req)
+	case "empty":
+		var yes bool
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s trash empty [-yes]\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.BoolVar(&yes, "yes", yes, "do not ask for confirmation")
+		fs.Parse(cliargs[1:])
+		if !yes {
+			if ok, err := confirm("Permanently delete all documents in trash?"); err != nil || !ok {
+				return err
+			}
+		}
+		return apiCall(ctx, token, apiURL("documents.empty_trash"), struct{}{})
+	}
+	return usage
+}
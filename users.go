@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"strings"
+	"time"
+)
+
+func handleUsers(ctx context.Context, token authToken, cliargs []string) error {
+	usage := fmt.Errorf("usage: %s users list|info|invite|suspend|activate [flags] ...", exeName)
+	if len(cliargs) == 0 {
+		return usage
+	}
+	switch cmd := cliargs[0]; cmd {
+	case "list":
+		var dstFile, query string
+		filter := "all"
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s users list [flags]\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+		fs.StringVar(&query, "query", query, "only list users whose name or email match this text")
+		fs.StringVar(&filter, "filter", filter, "users to list: all, active, suspended, invited, admins, members, or viewers")
+		fs.Parse(cliargs[1:])
+		var buf bytes.Buffer
+		out := []workspaceUser{}
+		for u, err := range listUsers(ctx, token, query, filter) {
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				out = append(out, u)
+				continue
+			}
+			fmt.Fprintf(&buf, "%s\t%s\t%s\t%s\t%s\n", u.ID, u.Email, u.Role, u.state(), u.Name)
+		}
+		if jsonOutput {
+			return writeJSON(dstFile, out)
+		}
+		return writeResult(dstFile, buf.Bytes())
+	case "info", "suspend", "activate":
+		var email string
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s users %s user-id|-email address\n", exeName, cmd)
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&email, "email", email, "find user by email `address` instead of id")
+		args := parseInterspersed(fs, cliargs[1:])
+		if (email == "") == (len(args) == 0) || len(args) > 1 {
+			return errors.New("want either a single user id or -email flag")
+		}
+		var id string
+		if len(args) != 0 {
+			id = args[0]
+		}
+		u, err := findUser(ctx, token, id, email)
+		if err != nil {
+			return err
+		}
+		if cmd != "info" {
+			req := struct {
+				Id string `json:"id"`
+			}{Id: u.ID}
+			res, err := apiRequest[workspaceUser](ctx, token, apiURL("users."+cmd), req)
+			if err != nil {
+				return err
+			}
+			u = &res
+		}
+		if jsonOutput {
+			return writeJSON("", u)
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\n", u.ID, u.Email, u.Role, u.state(), u.Name)
+		return nil
+	case "invite":
+		var csvFile, name string
+		role := "member"
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s users invite [flags] email...\n", exeName)
+			fmt.Fprintf(fs.Output(), "       %s users invite -csv file\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&csvFile, "csv", csvFile, "CSV `file` with email, name, and optional role columns, one user per row; - reads it from stdin")
+		fs.StringVar(&name, "name", name, "name of the invited user, if not set, the email is used")
+		fs.StringVar(&role, "role", role, "role of the invited users: admin, member, or viewer")
+		args := parseInterspersed(fs, cliargs[1:])
+		var invites []userInvite
+		for _, email := range args {
+			invites = append(invites, userInvite{Email: email, Name: cmp.Or(name, email), Role: role})
+		}
+		if csvFile != "" {
+			list, err := readInvites(csvFile, role)
+			if err != nil {
+				return err
+			}
+			invites = append(invites, list...)
+		}
+		if len(invites) == 0 {
+			return errors.New("want emails of the users to invite, or -csv flag")
+		}
+		// invite in batches, as the API limits the number of invites per request
+		const batchSize = 20
+		for i := 0; i < len(invites); i += batchSize {
+			req := struct {
+				Invites []userInvite `json:"invites"`
+			}{Invites: invites[i:min(i+batchSize, len(invites))]}
+			res, err := apiRequest[struct {
+				Users []workspaceUser `json:"users"`
+			}](ctx, token, apiURL("users.invite"), req)
+			if err != nil {
+				return err
+			}
+			for _, u := range res.Users {
+				fmt.Printf("%s\t%s\n", u.ID, u.Email)
+			}
+		}
+		return nil
+	}
+	return usage
+}
+
+// workspaceUser holds user attributes returned by the users.* endpoints
+type workspaceUser struct {
+	user
+	Role         string     `json:"role"`
+	IsSuspended  bool       `json:"isSuspended"`
+	LastActiveAt *time.Time `json:"lastActiveAt"` // nil for users who have not signed in yet
+}
+
+func (u *workspaceUser) state() string {
+	switch {
+	case u.IsSuspended:
+		return "suspended"
+	case u.LastActiveAt == nil:
+		return "invited"
+	}
+	return "active"
+}
+
+func listUsers(ctx context.Context, token authToken, query, filter string) iter.Seq2[workspaceUser, error] {
+	return paginate[workspaceUser](ctx, token, apiURL("users.list"), 0, func(p pagination) any {
+		return struct {
+			pagination
+			Query  string `json:"query,omitempty"`
+			Filter string `json:"filter,omitempty"`
+		}{pagination: p, Query: query, Filter: filter}
+	})
+}
+
+// findUser fetches user by id, or looks it up by email if it is not empty
+func findUser(ctx context.Context, token authToken, id, email string) (*workspaceUser, error) {
+	if email == "" {
+		req := struct {
+			Id string `json:"id"`
+		}{Id: id}
+		return apiRequest[*workspaceUser](ctx, token, apiURL("users.info"), req)
+	}
+	for u, err := range listUsers(ctx, token, email, "all") {
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(u.Email, email) {
+			return &u, nil
+		}
+	}
+	return nil, fmt.Errorf("no user with email %s", email)
+}
+
+type userInvite struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	Role  string `json:"role"`
+}
+
+// readInvites reads CSV file with email, name, and optional role columns.
+// A header row, if present, is skipped.
+func readInvites(name, defaultRole string) ([]userInvite, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	var out []userInvite
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(rec[0], "email") {
+			continue
+		}
+		if !strings.Contains(rec[0], "@") {
+			return nil, fmt.Errorf("%s:%d: invalid email %q", name, line, rec[0])
+		}
+		inv := userInvite{Email: rec[0], Name: rec[0], Role: defaultRole}
+		if len(rec) > 1 && rec[1] != "" {
+			inv.Name = rec[1]
+		}
+		if len(rec) > 2 && rec[2] != "" {
+			inv.Role = rec[2]
+		}
+		out = append(out, inv)
+	}
+	return out, nil
+}
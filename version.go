@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, set with the linker flags like:
+//
+//	go build -ldflags "-X main.version=v1.2.3 -X main.commit=abcdef -X main.buildDate=2024-12-31"
+//
+// If not set, they are filled from the module build information where
+// possible.
+var (
+	version   string
+	commit    string
+	buildDate string
+)
+
+func init() {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	if version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		version = bi.Main.Version
+	}
+	var modified bool
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if commit == "" {
+				commit = s.Value
+			}
+		case "vcs.time":
+			if buildDate == "" {
+				buildDate = s.Value
+			}
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if modified && commit != "" {
+		commit += "-dirty"
+	}
+}
+
+func handleVersion(context.Context, authToken, []string) error {
+	fmt.Printf("%s %s\n", exeName, versionString())
+	fmt.Printf("commit:\t%s\n", orUnknown(commit))
+	fmt.Printf("built:\t%s\n", orUnknown(buildDate))
+	fmt.Printf("go:\t%s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return nil
+}
+
+func versionString() string { return orUnknown(version) }
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchFile calls fn once, and then every time the file changes, until the
+// context is canceled. Errors returned by fn are logged, but do not stop
+// watching.
+func watchFile(ctx context.Context, name string, fn func() error) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	// watch the directory rather than the file itself, because many editors
+	// save files by writing a new file and renaming it over the old one
+	if err := w.Add(filepath.Dir(name)); err != nil {
+		return err
+	}
+	run := func() {
+		if err := fn(); err != nil {
+			log.Printf("%s: %v", name, err)
+			return
+		}
+		log.Printf("%s: updated", name)
+	}
+	run()
+	name = filepath.Clean(name)
+	// editors may generate several events per save, coalesce them
+	const delay = 200 * time.Millisecond
+	timer := time.NewTimer(delay)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-w.Errors:
+			return err
+		case ev := <-w.Events:
+			if filepath.Clean(ev.Name) != name || ev.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			timer.Reset(delay)
+		case <-timer.C:
+			run()
+		}
+	}
+}
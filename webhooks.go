@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+func handleWebhooks(ctx context.Context, token authToken, cliargs []string) error {
+	usage := fmt.Errorf("usage: %s webhooks list|create|delete [flags] ...", exeName)
+	if len(cliargs) == 0 {
+		return usage
+	}
+	switch cliargs[0] {
+	case "list":
+		var dstFile string
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s webhooks list [flags]\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&dstFile, "o", dstFile, "file to save result to, if not set, it will be printed to stdout")
+		fs.Parse(cliargs[1:])
+		var buf bytes.Buffer
+		out := []webhook{}
+		hooks := paginate[webhook](ctx, token, apiURL("webhookSubscriptions.list"), 0, func(p pagination) any { return p })
+		for w, err := range hooks {
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				out = append(out, w)
+				continue
+			}
+			state := "enabled"
+			if !w.Enabled {
+				state = "disabled"
+			}
+			fmt.Fprintf(&buf, "%s\t%s\t%s\t%s\t%s\n", w.ID, state, w.URL, strings.Join(w.Events, ","), w.Name)
+		}
+		if jsonOutput {
+			return writeJSON(dstFile, out)
+		}
+		return writeResult(dstFile, buf.Bytes())
+	case "create":
+		var name, secret string
+		var events []string
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s webhooks create -name name [flags] url\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.StringVar(&name, "name", name, "webhook name")
+		fs.StringVar(&secret, "secret", secret, "secret to sign webhook requests with, see listen subcommand")
+		fs.Func("event", "`event` to deliver, like documents.update, or a whole category, like documents;\n"+
+			"can be repeated, if not set, all events are delivered", func(s string) error {
+			events = append(events, s)
+			return nil
+		})
+		args := parseInterspersed(fs, cliargs[1:])
+		if len(args) != 1 {
+			return errors.New("want exactly one webhook url")
+		}
+		if name == "" {
+			return errors.New("-name flag must be set")
+		}
+		if len(events) == 0 {
+			events = []string{"*"}
+		}
+		req := struct {
+			Name   string   `json:"name"`
+			URL    string   `json:"url"`
+			Events []string `json:"events"`
+			Secret string   `json:"secret,omitempty"`
+		}{Name: name, URL: args[0], Events: events, Secret: secret}
+		res, err := apiRequest[webhook](ctx, token, apiURL("webhookSubscriptions.create"), req)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return writeJSON("", res)
+		}
+		fmt.Println(res.ID)
+		return nil
+	case "delete":
+		fs := flag.NewFlagSet("", flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s webhooks delete webhook-id\n", exeName)
+			fs.PrintDefaults()
+		}
+		fs.Parse(cliargs[1:])
+		if fs.NArg() != 1 {
+			return errors.New("want exactly one webhook id, see webhooks list")
+		}
+		req := struct {
+			Id string `json:"id"`
+		}{Id: fs.Arg(0)}
+		return apiCall(ctx, token, apiURL("webhookSubscriptions.delete"), req)
+	}
+	return usage
+}
+
+// webhook holds a subset of webhook subscription attributes
+type webhook struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`
+	Enabled bool     `json:"enabled"`
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+)
+
+func handleWhoami(ctx context.Context, token authToken, cliargs []string) error {
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s whoami\n", exeName)
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nPrints the user and team the token belongs to, failing if the token is not valid.")
+	}
+	fs.Parse(cliargs)
+	info, err := fetchAuthInfo(ctx, token)
+	if err != nil {
+		return err
+	}
+	info.Team.URL = cmp.Or(info.Team.URL, webURL(""))
+	if jsonOutput {
+		return writeJSON("", info)
+	}
+	fmt.Printf("user:\t%s <%s> (%s), %s\n", info.User.Name, info.User.Email, info.User.ID, info.User.Role)
+	fmt.Printf("team:\t%s (%s)\n", info.Team.Name, info.Team.ID)
+	fmt.Printf("url:\t%s\n", info.Team.URL)
+	return nil
+}
+
+// authInfo describes the user and team the token belongs to
+type authInfo struct {
+	User workspaceUser `json:"user"`
+	Team struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"team"`
+}
+
+func fetchAuthInfo(ctx context.Context, token authToken) (*authInfo, error) {
+	return apiRequest[*authInfo](ctx, token, apiURL("auth.info"), struct{}{})
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"rsc.io/markdown"
+)
+
+// wikiLink is an Obsidian-style link like [[Note#Heading|text]], or an embed
+// like ![[image.png]]
+type wikiLink struct {
+	embed   bool
+	target  string // note name or file path, empty for links within the same note
+	heading string
+	text    string // display text, empty if not set
+}
+
+var wikiLinkRe = regexp.MustCompile(`(!?)\[\[([^\[\]|#]*)(?:#([^\[\]|]*))?(?:\|([^\[\]]*))?\]\]`)
+
+// label returns link text the way Obsidian displays it
+func (w wikiLink) label() string {
+	switch {
+	case w.text != "":
+		return w.text
+	case w.heading == "":
+		return w.target
+	case w.target == "":
+		return w.heading
+	}
+	return w.target + " > " + w.heading
+}
+
+// inline returns markdown link or image pointing to the url
+func (w wikiLink) inline(url string) markdown.Inline {
+	if w.embed {
+		// text of embeds is often image size like 300 or 300x200
+		alt := w.text
+		if alt == "" || imageSizeRe.MatchString(alt) {
+			alt = fileTitle(w.target)
+		}
+		return &markdown.Image{Inner: markdown.Inlines{&markdown.Plain{Text: alt}}, URL: url}
+	}
+	return &markdown.Link{Inner: markdown.Inlines{&markdown.Plain{Text: w.label()}}, URL: url}
+}
+
+var imageSizeRe = regexp.MustCompile(`^\d+(x\d+)?$`)
+
+// replaceWikiLinks replaces wikilinks found in the document text with
+// inlines returned by fn. Wikilinks fn returns nil for are kept as is.
+func replaceWikiLinks(doc *markdown.Document, fn func(wikiLink) markdown.Inline) {
+	var inlines func(markdown.Inlines) markdown.Inlines
+	inlines = func(in markdown.Inlines) markdown.Inlines {
+		var out markdown.Inlines
+		for _, inl := range in {
+			switch x := inl.(type) {
+			case *markdown.Strong:
+				x.Inner = inlines(x.Inner)
+			case *markdown.Emph:
+				x.Inner = inlines(x.Inner)
+			case *markdown.Del:
+				x.Inner = inlines(x.Inner)
+			case *markdown.Plain:
+				var last int
+				for _, m := range wikiLinkRe.FindAllStringSubmatchIndex(x.Text, -1) {
+					w := wikiLink{embed: m[3] > m[2], target: strings.TrimSpace(x.Text[m[4]:m[5]])}
+					if m[6] != -1 {
+						w.heading = strings.TrimSpace(x.Text[m[6]:m[7]])
+					}
+					if m[8] != -1 {
+						w.text = strings.TrimSpace(x.Text[m[8]:m[9]])
+					}
+					repl := fn(w)
+					if repl == nil {
+						continue
+					}
+					if m[0] > last {
+						out = append(out, &markdown.Plain{Text: x.Text[last:m[0]]})
+					}
+					out = append(out, repl)
+					last = m[1]
+				}
+				if last != 0 {
+					if last < len(x.Text) {
+						out = append(out, &markdown.Plain{Text: x.Text[last:]})
+					}
+					continue
+				}
+			}
+			out = append(out, inl)
+		}
+		return out
+	}
+	var blocks func(markdown.Block)
+	blocks = func(block markdown.Block) {
+		switch bl := block.(type) {
+		case *markdown.Item:
+			for _, b := range bl.Blocks {
+				blocks(b)
+			}
+		case *markdown.List:
+			for _, b := range bl.Items {
+				blocks(b)
+			}
+		case *markdown.Quote:
+			for _, b := range bl.Blocks {
+				blocks(b)
+			}
+		case *markdown.Paragraph:
+			bl.Text.Inline = inlines(bl.Text.Inline)
+		case *markdown.Heading:
+			bl.Text.Inline = inlines(bl.Text.Inline)
+		case *markdown.Text:
+			bl.Inline = inlines(bl.Inline)
+		case *markdown.Table:
+			for _, b := range bl.Header {
+				blocks(b)
+			}
+			for _, row := range bl.Rows {
+				for _, b := range row {
+					blocks(b)
+				}
+			}
+		}
+	}
+	for _, b := range doc.Blocks {
+		blocks(b)
+	}
+}
+
+// resolveWikiLinks replaces wikilinks to documents like [[Title]] or
+// [[Title#Heading]] with links to documents found by their exact titles.
+// Embeds are left as is. The cache map of lowercase titles to document urls
+// is used to avoid looking up the same title more than once across calls.
+func resolveWikiLinks(ctx context.Context, token authToken, doc *markdown.Document, cache map[string]string) error {
+	var err error
+	replaceWikiLinks(doc, func(w wikiLink) markdown.Inline {
+		if err != nil || w.embed {
+			return nil
+		}
+		var link string
+		if w.target != "" {
+			key := strings.ToLower(w.target)
+			u, ok := cache[key]
+			if !ok {
+				var d *document
+				if d, err = findTitle(ctx, token, w.target); err != nil {
+					err = fmt.Errorf("resolving [[%s]]: %w", w.target, err)
+					return nil
+				}
+				u = d.URL
+				cache[key] = u
+			}
+			link = u
+		}
+		if w.heading != "" {
+			link += "#" + slugOutline(w.heading)
+		}
+		if link == "" {
+			return nil
+		}
+		return w.inline(link)
+	})
+	return err
+}